@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// blobsDir is the directory below the cache root holding blobs
+// as ALGORITHM/HEX files.
+const blobsDir = "blobs"
+
+// locksDir is the directory below the cache root holding the lock
+// files of blobs being downloaded, as ALGORITHM/HEX files.
+const locksDir = "locks"
+
+// pruneTarget is the fraction of the maximum size a cache that grew
+// beyond it is pruned down to, so that Put does not prune on every call
+// once the cache is full.
+const pruneTarget = 0.9
+
+// Cache is an on-disk, content-addressed store of blobs shared by
+// every mount using the same cache directory. Processes sharing it
+// coordinate downloads with Lock; blobs are written atomically, so a
+// reader never sees partial content even without the lock.
+type Cache struct {
+	dir string
+
+	mu sync.Mutex
+	// maxSize is the size Put keeps the cache under, or 0 for no limit.
+	maxSize int64
+	// size is the size of the cache when it was last listed plus the
+	// blobs added since, or -1 before it is listed.
+	size int64
+}
+
+// New returns a Cache rooted at dir, creating it if needed.
+func New(dir string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Join(dir, blobsDir), 0750); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir, size: -1}, nil
+}
+
+// SetMaxSize limits the cache to maxSize bytes: when Put grows it
+// beyond, the least recently accessed blobs are deleted. Blobs added by
+// other processes sharing the cache are counted when it is next listed.
+// A maxSize of 0 removes the limit.
+func (c *Cache) SetMaxSize(maxSize int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxSize = maxSize
+}
+
+// Path returns the file that holds the blob with digest d.
+func (c *Cache) Path(d digest.Digest) string {
+	return filepath.Join(c.dir, blobsDir, d.Algorithm().String(), d.Encoded())
+}
+
+// Has reports whether the blob described by desc is cached.
+func (c *Cache) Has(desc ocispec.Descriptor) bool {
+	if desc.Digest.Validate() != nil {
+		return false
+	}
+	info, err := os.Stat(c.Path(desc.Digest))
+	return err == nil && info.Size() == desc.Size
+}
+
+// Get returns the content of the blob described by desc. It returns an
+// error satisfying errors.Is(err, os.ErrNotExist) if it is not cached.
+// A blob whose content does not match desc is deleted and reported as
+// not cached, so that callers fetch it again; IsCorrupt reports this
+// case. The modification time of the blob is set to now, recording the
+// access for Prune.
+func (c *Cache) Get(desc ocispec.Descriptor) ([]byte, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, err
+	}
+	path := c.Path(desc.Digest)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckContent(desc, data); err != nil {
+		if err := c.Remove(desc.Digest); err != nil && !IsNotExist(err) {
+			return nil, err
+		}
+		return nil, &corruptError{err: err}
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, nil
+}
+
+// corruptError is returned by Get for a cached blob that did not match
+// its descriptor and was deleted.
+type corruptError struct {
+	err error
+}
+
+func (e *corruptError) Error() string {
+	return fmt.Sprintf("cached %v, deleted it", e.err)
+}
+
+// Is makes a deleted blob not cached for errors.Is.
+func (e *corruptError) Is(target error) bool {
+	return target == os.ErrNotExist
+}
+
+func (e *corruptError) Unwrap() error {
+	return e.err
+}
+
+// IsCorrupt reports whether err means Get found and deleted a blob that
+// did not match its descriptor.
+func IsCorrupt(err error) bool {
+	var corrupt *corruptError
+	return errors.As(err, &corrupt)
+}
+
+// CheckContent returns an error if data does not have the size and
+// digest of desc.
+func CheckContent(desc ocispec.Descriptor, data []byte) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return err
+	}
+	if int64(len(data)) != desc.Size {
+		return fmt.Errorf("blob %s has size %d, expected %d", desc.Digest, len(data), desc.Size)
+	}
+	if actual := desc.Digest.Algorithm().FromBytes(data); actual != desc.Digest {
+		return fmt.Errorf("blob %s has digest %s", desc.Digest, actual)
+	}
+	return nil
+}
+
+// Put verifies data against desc and adds it to the cache. The blob is
+// written to a temporary file and renamed into place, so readers never
+// see partial content. If the cache then exceeds its maximum size, it is
+// pruned.
+func (c *Cache) Put(desc ocispec.Descriptor, data []byte) error {
+	if err := CheckContent(desc, data); err != nil {
+		return err
+	}
+
+	path := c.Path(desc.Digest)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+desc.Digest.Encoded()+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0640); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return c.added(desc.Size)
+}
+
+// added records n bytes added to the cache and prunes it down to
+// pruneTarget of its maximum size if it grew beyond.
+func (c *Cache) added(n int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxSize <= 0 {
+		return nil
+	}
+	if c.size >= 0 {
+		c.size += n
+		if c.size <= c.maxSize {
+			return nil
+		}
+	}
+	size, err := c.Size()
+	if err != nil {
+		return err
+	}
+	if size > c.maxSize {
+		if _, err := c.Prune(int64(float64(c.maxSize)*pruneTarget), 0); err != nil {
+			return fmt.Errorf("error pruning cache: %w", err)
+		}
+		if size, err = c.Size(); err != nil {
+			return err
+		}
+	}
+	c.size = size
+	return nil
+}
+
+// Lock takes an exclusive lock on downloading the blob d, waiting while
+// another process or goroutine holds it, and returns the function that
+// releases it. waited reports whether it had to wait, in which case the
+// holder has likely added the blob and the caller should check with Get
+// before downloading it again.
+func (c *Cache) Lock(d digest.Digest) (unlock func(), waited bool, err error) {
+	if err := d.Validate(); err != nil {
+		return nil, false, err
+	}
+	path := c.lockPath(d)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, false, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, false, err
+	}
+	waited, err = lockFile(f)
+	if err != nil {
+		f.Close()
+		return nil, false, err
+	}
+	return func() { f.Close() }, waited, nil
+}
+
+// lockPath returns the lock file of the blob d. Lock files are kept
+// after use: deleting one while another process waits on it would let a
+// third process lock a new file and download the blob concurrently.
+func (c *Cache) lockPath(d digest.Digest) string {
+	return filepath.Join(c.dir, locksDir, d.Algorithm().String(), d.Encoded())
+}
+
+// IsNotExist reports whether err means a blob is not cached.
+func IsNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
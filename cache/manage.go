@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// refsDir is the directory below the cache root holding, for each blob,
+// an ALGORITHM/HEX file listing the references it was fetched for.
+const refsDir = "refs"
+
+// Entry describes a cached blob.
+type Entry struct {
+	Digest digest.Digest `json:"digest"`
+	Size   int64         `json:"size"`
+	// LastAccess is when the blob was last added or read.
+	LastAccess time.Time `json:"lastAccess"`
+	// References are the references the blob was fetched for.
+	References []string `json:"references,omitempty"`
+}
+
+// refsPath returns the file listing the references of the blob d.
+func (c *Cache) refsPath(d digest.Digest) string {
+	return filepath.Join(c.dir, refsDir, d.Algorithm().String(), d.Encoded())
+}
+
+// AddReference records that the blob d was fetched for reference.
+func (c *Cache) AddReference(d digest.Digest, reference string) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	refs, err := c.references(d)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref == reference {
+			return nil
+		}
+	}
+
+	path := c.refsPath(d)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, reference); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// references returns the references recorded for the blob d.
+func (c *Cache) references(d digest.Digest) ([]string, error) {
+	f, err := os.Open(c.refsPath(d))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var refs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			refs = append(refs, line)
+		}
+	}
+	return refs, scanner.Err()
+}
+
+// List returns every cached blob, least recently accessed first.
+// Temporary files of blobs being added are skipped.
+func (c *Cache) List() ([]Entry, error) {
+	var entries []Entry
+	root := filepath.Join(c.dir, blobsDir)
+	err := filepath.WalkDir(root, func(path string, dirEntry fs.DirEntry, err error) error {
+		if err != nil || dirEntry.IsDir() || strings.HasPrefix(dirEntry.Name(), ".") {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		d := digest.Digest(strings.Replace(filepath.ToSlash(rel), "/", ":", 1))
+		if d.Validate() != nil {
+			return nil
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+		refs, err := c.references(d)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, Entry{
+			Digest:     d,
+			Size:       info.Size(),
+			LastAccess: info.ModTime(),
+			References: refs,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastAccess.Before(entries[j].LastAccess)
+	})
+	return entries, nil
+}
+
+// Remove deletes the blob d and its references. Its lock file is kept, see
+// lockPath. It returns an error satisfying IsNotExist if the blob is not
+// cached.
+func (c *Cache) Remove(d digest.Digest) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if err := os.Remove(c.Path(d)); err != nil {
+		return err
+	}
+	if err := os.Remove(c.refsPath(d)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Size returns the total size of the cached blobs.
+func (c *Cache) Size() (int64, error) {
+	entries, err := c.List()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+	return total, nil
+}
+
+// staleTempAge is the age after which Prune deletes the temporary file
+// of a blob being added, assuming its download was interrupted.
+const staleTempAge = time.Hour
+
+// Prune deletes blobs last accessed before olderThan ago, then the least
+// recently accessed blobs until the cache holds at most maxSize bytes.
+// A zero olderThan or maxSize disables that limit. It returns the
+// deleted blobs. Temporary files left by interrupted downloads are
+// deleted too.
+func (c *Cache) Prune(maxSize int64, olderThan time.Duration) ([]Entry, error) {
+	if err := c.removeStaleTemp(); err != nil {
+		return nil, err
+	}
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	var removed []Entry
+	cutoff := time.Now().Add(-olderThan)
+	for _, entry := range entries {
+		expired := olderThan > 0 && entry.LastAccess.Before(cutoff)
+		tooBig := maxSize > 0 && total > maxSize
+		if !expired && !tooBig {
+			break
+		}
+		if err := c.Remove(entry.Digest); err != nil && !IsNotExist(err) {
+			return removed, err
+		}
+		total -= entry.Size
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+// removeStaleTemp deletes temporary blob files older than staleTempAge.
+func (c *Cache) removeStaleTemp() error {
+	cutoff := time.Now().Add(-staleTempAge)
+	return filepath.WalkDir(filepath.Join(c.dir, blobsDir), func(path string, dirEntry fs.DirEntry, err error) error {
+		if err != nil || dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), ".tmp") {
+			return err
+		}
+		info, err := dirEntry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Verify re-hashes every cached blob and deletes those whose content
+// does not match their digest. It returns the deleted blobs.
+func (c *Cache) Verify() ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	var removed []Entry
+	for _, entry := range entries {
+		ok, err := c.verify(entry.Digest)
+		if err != nil {
+			return removed, err
+		}
+		if ok {
+			continue
+		}
+		if err := c.Remove(entry.Digest); err != nil && !IsNotExist(err) {
+			return removed, err
+		}
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+// verify reports whether the content of the blob d matches d.
+func (c *Cache) verify(d digest.Digest) (bool, error) {
+	f, err := os.Open(c.Path(d))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	verifier := d.Verifier()
+	if _, err := io.Copy(verifier, f); err != nil {
+		return false, err
+	}
+	return verifier.Verified(), nil
+}
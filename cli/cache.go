@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/spf13/cobra"
+	"github.com/uor-framework/uor-client-go/util/examples"
+
+	"github.com/uor-framework/uor-fuse-go/cache"
+	"github.com/uor-framework/uor-fuse-go/config"
+)
+
+var clientCacheExamples = []examples.Example{
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "cache ls",
+		Descriptions: []string{
+			"List cached blobs, least recently used first.",
+		},
+	},
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "cache prune --max-size 10G --older-than 30d",
+		Descriptions: []string{
+			"Delete blobs unused for 30 days, then the least recently used until the cache is at most 10 GiB.",
+		},
+	},
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "cache verify",
+		Descriptions: []string{
+			"Re-hash cached blobs and delete corrupt ones.",
+		},
+	},
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "cache rm sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		Descriptions: []string{
+			"Delete a cached blob.",
+		},
+	},
+}
+
+// CacheOptions describe configuration options that can
+// be set using the cache subcommands.
+type CacheOptions struct {
+	*config.RootOptions
+	Output    string
+	MaxSize   string
+	OlderThan string
+	Digests   []string
+}
+
+// NewCacheCmd creates a new cobra.Command for the cache subcommand.
+func NewCacheCmd(rootOpts *config.RootOptions) *cobra.Command {
+	o := CacheOptions{RootOptions: rootOpts}
+
+	cmd := &cobra.Command{
+		Use:     "cache",
+		Short:   "Manage the blob cache shared by mounts",
+		Example: examples.FormatExamples(clientCacheExamples...),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	ls := &cobra.Command{
+		Use:   "ls",
+		Short: "List cached blobs with their size, last access and references",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if o.Output != "text" && o.Output != "json" {
+				return fmt.Errorf("unknown output format %q, must be text or json", o.Output)
+			}
+			return o.List()
+		},
+	}
+	ls.Flags().StringVarP(&o.Output, "output", "o", "text", "output format (text, json)")
+
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete blobs unused for a time, then the least recently used down to a size",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return o.Prune()
+		},
+	}
+	prune.Flags().StringVar(&o.MaxSize, "max-size", o.MaxSize, "largest total size to keep, e.g. 500M or 10G")
+	prune.Flags().StringVar(&o.OlderThan, "older-than", o.OlderThan, "delete blobs not accessed for this long, e.g. 12h or 30d")
+
+	verify := &cobra.Command{
+		Use:   "verify",
+		Short: "Re-hash cached blobs and delete corrupt ones",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return o.Verify()
+		},
+	}
+
+	rm := &cobra.Command{
+		Use:   "rm DIGEST...",
+		Short: "Delete cached blobs",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			o.Digests = args
+			return o.Remove()
+		},
+	}
+
+	cmd.AddCommand(ls, prune, verify, rm)
+	return cmd
+}
+
+func (o *CacheOptions) open() (*cache.Cache, error) {
+	if o.CacheDir == "" {
+		return nil, errors.New("no cache directory configured")
+	}
+	return cache.New(o.CacheDir)
+}
+
+// List prints the cached blobs, least recently accessed first.
+func (o *CacheOptions) List() error {
+	c, err := o.open()
+	if err != nil {
+		return err
+	}
+	entries, err := c.List()
+	if err != nil {
+		return err
+	}
+
+	if o.Output == "json" {
+		if entries == nil {
+			entries = []cache.Entry{}
+		}
+		encoder := json.NewEncoder(o.IOStreams.Out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	var total int64
+	w := tabwriter.NewWriter(o.IOStreams.Out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "DIGEST\tSIZE\tLAST ACCESS\tREFERENCES")
+	for _, entry := range entries {
+		total += entry.Size
+		refs := strings.Join(entry.References, ",")
+		if refs == "" {
+			refs = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Digest, cache.FormatSize(entry.Size),
+			entry.LastAccess.Format(time.RFC3339), refs)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(o.IOStreams.Out, "%d blobs, %s in %s\n", len(entries), cache.FormatSize(total), o.CacheDir)
+	return err
+}
+
+// Prune deletes blobs as limited by MaxSize and OlderThan.
+func (o *CacheOptions) Prune() error {
+	if o.MaxSize == "" && o.OlderThan == "" {
+		return errors.New("at least one of --max-size and --older-than must be specified")
+	}
+	var maxSize int64
+	var olderThan time.Duration
+	var err error
+	if o.MaxSize != "" {
+		if maxSize, err = cache.ParseSize(o.MaxSize); err != nil {
+			return fmt.Errorf("invalid --max-size: %w", err)
+		}
+	}
+	if o.OlderThan != "" {
+		if olderThan, err = parseAge(o.OlderThan); err != nil {
+			return fmt.Errorf("invalid --older-than: %w", err)
+		}
+	}
+
+	c, err := o.open()
+	if err != nil {
+		return err
+	}
+	removed, err := c.Prune(maxSize, olderThan)
+	o.printRemoved("Pruned", removed)
+	return err
+}
+
+// Verify deletes cached blobs whose content does not match their digest.
+func (o *CacheOptions) Verify() error {
+	c, err := o.open()
+	if err != nil {
+		return err
+	}
+	removed, err := c.Verify()
+	for _, entry := range removed {
+		o.Logger.Warnf("deleted corrupt blob %s", entry.Digest)
+	}
+	o.printRemoved("Deleted corrupt", removed)
+	return err
+}
+
+// Remove deletes the blobs in Digests.
+func (o *CacheOptions) Remove() error {
+	c, err := o.open()
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, arg := range o.Digests {
+		d, err := digest.Parse(arg)
+		if err == nil {
+			err = c.Remove(d)
+		}
+		if cache.IsNotExist(err) {
+			err = errors.New("not cached")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", arg, err))
+			continue
+		}
+		fmt.Fprintln(o.IOStreams.Out, d)
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (o *CacheOptions) printRemoved(action string, removed []cache.Entry) {
+	var total int64
+	for _, entry := range removed {
+		total += entry.Size
+	}
+	fmt.Fprintf(o.IOStreams.Out, "%s %d blobs, %s\n", action, len(removed), cache.FormatSize(total))
+}
+
+// parseAge parses a duration as time.ParseDuration does, also accepting
+// a whole number of days such as "30d".
+func parseAge(s string) (time.Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%q is not a duration", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%q is negative", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/uor-framework/uor-client-go/util/examples"
+
+	"github.com/uor-framework/uor-fuse-go/config"
+)
+
+var clientCatExamples = []examples.Example{
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "cat localhost:5001/test:latest models/config.json",
+		Descriptions: []string{
+			"Print a file of a collection without mounting it.",
+		},
+	},
+}
+
+// CatOptions describe configuration options that can
+// be set using the cat subcommand.
+type CatOptions struct {
+	MountOptions
+	Path string
+}
+
+// NewCatCmd creates a new cobra.Command for the cat subcommand.
+func NewCatCmd(rootOpts *config.RootOptions) *cobra.Command {
+	o := CatOptions{MountOptions: MountOptions{RootOptions: rootOpts}}
+
+	cmd := &cobra.Command{
+		Use:           "cat [flags] SRC|NAME=SRC PATH",
+		Short:         "Print a file of a UOR collection without mounting it",
+		Example:       examples.FormatExamples(clientCatExamples...),
+		SilenceErrors: false,
+		SilenceUsage:  false,
+		Args:          cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run(cmd.Context())
+		},
+	}
+
+	o.addSourceFlags(cmd)
+
+	return cmd
+}
+
+func (o *CatOptions) Complete(args []string) error {
+	if len(args) < 2 {
+		return errors.New("bug: expecting two arguments")
+	}
+	o.setSources(args[:1])
+	o.Path = args[1]
+	return nil
+}
+
+func (o *CatOptions) Validate() error {
+	return o.validateSources()
+}
+
+func (o *CatOptions) Run(ctx context.Context) error {
+	if err := o.logToStderr(); err != nil {
+		return err
+	}
+	uorFs, err := o.newUorFs(ctx, nil)
+	if err != nil {
+		return err
+	}
+	data, err := uorFs.ReadFile(ctx, o.Path)
+	if err != nil {
+		return err
+	}
+	_, err = o.IOStreams.Out.Write(data)
+	return err
+}
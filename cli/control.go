@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uor-framework/uor-fuse-go/control"
+	"github.com/uor-framework/uor-fuse-go/fs"
+	"github.com/uor-framework/uor-fuse-go/query"
+)
+
+// mountController carries out control socket commands for a mount.
+type mountController struct {
+	o     *MountOptions
+	uorFs *fs.UorFs
+}
+
+func (c *mountController) Status() (*control.Status, error) {
+	stats := c.uorFs.Stats()
+	status := &control.Status{
+		PID:         os.Getpid(),
+		MountPoint:  c.o.MountPoint,
+		Source:      c.o.Source,
+		Filter:      query.Format(c.uorFs.Matcher()),
+		Nodes:       stats.Nodes,
+		Files:       stats.Files,
+		OpenFiles:   stats.OpenFiles,
+		CachedFiles: stats.CachedFiles,
+		CacheBytes:  stats.CacheBytes,
+		CacheHits:   stats.CacheHits,
+		CacheMisses: stats.CacheMisses,
+		Fetching:    stats.Fetching,
+		Prefetch: control.Prefetch{
+			Running: stats.Prefetch.Running > 0,
+			Total:   stats.Prefetch.Total,
+			Done:    stats.Prefetch.Done,
+			Failed:  stats.Prefetch.Failed,
+			Bytes:   stats.Prefetch.Bytes,
+		},
+		LastRefresh: stats.LastRefresh,
+		LogLevel:    c.o.Logger.Level(),
+	}
+	if c.o.Source != "" {
+		status.Digest = stats.Digests[""]
+	}
+	for _, spec := range c.uorFs.ListCollections() {
+		status.Collections = append(status.Collections, control.Collection{
+			Name:      spec.Name,
+			Reference: spec.Reference,
+			Digest:    stats.Digests[spec.Name],
+		})
+	}
+	sort.Slice(status.Collections, func(i, j int) bool {
+		return status.Collections[i].Name < status.Collections[j].Name
+	})
+	return status, nil
+}
+
+// SetFilter rebuilds the tree with the attribute query q in place of the
+// current filter, or without filter if q is empty.
+func (c *mountController) SetFilter(q string) error {
+	var matcher query.Expression
+	if q = strings.TrimSpace(q); q != "" {
+		var err error
+		if matcher, err = query.Parse(q); err != nil {
+			return err
+		}
+	}
+	client, err := c.o.newClient(matcher)
+	if err != nil {
+		return err
+	}
+	return c.uorFs.SetFilter(client, matcher)
+}
+
+// Refresh rebuilds the tree with a new client so updated tags are
+// resolved again.
+func (c *mountController) Refresh() error {
+	matcher := c.uorFs.Matcher()
+	client, err := c.o.newClient(matcher)
+	if err != nil {
+		return err
+	}
+	return c.uorFs.SetFilter(client, matcher)
+}
+
+// refreshEvery calls Refresh at each interval until ctx is done.
+func (c *mountController) refreshEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.o.Logger.Infof("Refreshing mounted references")
+			if err := c.Refresh(); err != nil {
+				c.o.Logger.Errorf("error refreshing: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *mountController) Flush() error {
+	c.uorFs.FlushCache()
+	return nil
+}
+
+func (c *mountController) SetLogLevel(level string) error {
+	if err := c.o.Logger.SetLevel(level); err != nil {
+		return err
+	}
+	c.o.Logger.Infof("Log level set to %s", level)
+	return nil
+}
+
+func (c *mountController) Prefetch(paths []string) error {
+	return c.uorFs.PrefetchFiles(context.Background(), fs.PrefetchOptions{Paths: paths, Jobs: c.o.PrefetchJobs})
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/uor-framework/uor-client-go/util/examples"
+
+	"github.com/uor-framework/uor-fuse-go/config"
+	"github.com/uor-framework/uor-fuse-go/fs"
+)
+
+var clientDiffExamples = []examples.Example{
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "diff localhost:5001/test:v1 localhost:5001/test:v2",
+		Descriptions: []string{
+			"Show files added, removed or modified between two collections, and changed attributes.",
+		},
+	},
+}
+
+// changeLetters abbreviate change kinds in text output.
+var changeLetters = map[string]string{
+	fs.ChangeAdded:      "A",
+	fs.ChangeRemoved:    "D",
+	fs.ChangeModified:   "M",
+	fs.ChangeAttributes: "T",
+}
+
+// DiffOptions describe configuration options that can
+// be set using the diff subcommand.
+type DiffOptions struct {
+	MountOptions
+	Old    string
+	New    string
+	Output string
+}
+
+// NewDiffCmd creates a new cobra.Command for the diff subcommand.
+func NewDiffCmd(rootOpts *config.RootOptions) *cobra.Command {
+	o := DiffOptions{MountOptions: MountOptions{RootOptions: rootOpts}}
+
+	cmd := &cobra.Command{
+		Use:           "diff [flags] SRC1 SRC2",
+		Short:         "Compare the files of two UOR collections",
+		Example:       examples.FormatExamples(clientDiffExamples...),
+		SilenceErrors: false,
+		SilenceUsage:  false,
+		Args:          cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run(cmd.Context())
+		},
+	}
+
+	o.addSourceFlags(cmd)
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "text", "output format (text, json)")
+
+	return cmd
+}
+
+func (o *DiffOptions) Complete(args []string) error {
+	if len(args) < 2 {
+		return errors.New("bug: expecting two arguments")
+	}
+	o.Old, o.New = args[0], args[1]
+	o.Source = o.Old
+	return nil
+}
+
+func (o *DiffOptions) Validate() error {
+	if o.Output != "text" && o.Output != "json" {
+		return fmt.Errorf("unknown output format %q, must be text or json", o.Output)
+	}
+	return o.validateSources()
+}
+
+func (o *DiffOptions) Run(ctx context.Context) error {
+	if err := o.logToStderr(); err != nil {
+		return err
+	}
+	oldFiles, err := o.listFiles(ctx, o.Old)
+	if err != nil {
+		return err
+	}
+	newFiles, err := o.listFiles(ctx, o.New)
+	if err != nil {
+		return err
+	}
+	changes := fs.Diff(oldFiles, newFiles)
+
+	if o.Output == "json" {
+		if changes == nil {
+			changes = []fs.Change{}
+		}
+		encoder := json.NewEncoder(o.IOStreams.Out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(changes)
+	}
+
+	for _, change := range changes {
+		switch change.Kind {
+		case fs.ChangeModified:
+			fmt.Fprintf(o.IOStreams.Out, "%s %s (%s -> %s)\n", changeLetters[change.Kind], change.Path, change.OldDigest, change.NewDigest)
+		default:
+			fmt.Fprintf(o.IOStreams.Out, "%s %s\n", changeLetters[change.Kind], change.Path)
+		}
+		keys := make([]string, 0, len(change.Attributes))
+		for key := range change.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			attribute := change.Attributes[key]
+			fmt.Fprintf(o.IOStreams.Out, "    %s: %s -> %s\n", key, orDash(string(attribute.Old)), orDash(string(attribute.New)))
+		}
+	}
+	return nil
+}
+
+// listFiles builds the tree of the collection reference and lists it
+// recursively. Each side is built from its own copy of the options.
+func (o *DiffOptions) listFiles(ctx context.Context, reference string) ([]fs.FileInfo, error) {
+	side := o.MountOptions
+	side.Source = reference
+	side.Logger.Infof("Resolving artifacts for reference %s", reference)
+	uorFs, err := side.newUorFs(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", reference, err)
+	}
+	return uorFs.List("", true, false)
+}
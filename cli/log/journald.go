@@ -0,0 +1,100 @@
+//go:build linux
+
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// journaldSocket is the socket of the journald native protocol.
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldSink sends entries to journald with each log field as a
+// journal field, so they can be matched with journalctl FIELD=value.
+type journaldSink struct {
+	conn *net.UnixConn
+	tag  string
+}
+
+func newJournaldSink(tag string) (Sink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to journald: %w", err)
+	}
+	return &journaldSink{conn: conn, tag: tag}, nil
+}
+
+func (s *journaldSink) send(entry *logrus.Entry) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", entry.Message)
+	writeJournalField(&b, "PRIORITY", fmt.Sprint(journalPriority(entry.Level)))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", s.tag)
+	for key, value := range entry.Data {
+		writeJournalField(&b, journalFieldName(key), fmt.Sprint(value))
+	}
+	_, err := s.conn.Write(b.Bytes())
+	return err
+}
+
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
+
+// writeJournalField appends a field in the native protocol format.
+// Values containing newlines are written length-prefixed.
+func writeJournalField(b *bytes.Buffer, name string, value string) {
+	b.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalFieldName converts a log field key to a valid journal field name:
+// upper case letters, digits and underscores, not starting with an
+// underscore or digit.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+	name = strings.TrimLeft(name, "_0123456789")
+	if name == "" {
+		return "FIELD"
+	}
+	return name
+}
+
+// journalPriority maps a logrus level to a syslog priority.
+func journalPriority(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 0
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	}
+	return 7
+}
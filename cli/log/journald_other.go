@@ -0,0 +1,9 @@
+//go:build !linux
+
+package log
+
+import "errors"
+
+func newJournaldSink(tag string) (Sink, error) {
+	return nil, errors.New("journald is only supported on linux")
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/sirupsen/logrus"
@@ -13,46 +14,93 @@ type Logger interface {
 	Warnf(string, ...interface{})
 	Debugf(string, ...interface{})
 	Fatalf(string, ...interface{})
+	// WithFields returns a Logger that adds fields to every entry.
+	WithFields(Fields) Logger
+	// SetLevel changes the level of the Logger and every Logger
+	// derived from it with WithFields.
+	SetLevel(string) error
+	// Level returns the current level.
+	Level() string
 }
 
+// Fields are structured key/value pairs attached to a log entry.
+type Fields map[string]interface{}
+
+// Log formats accepted by NewLogger.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 type standardLogger struct {
-	logger *logrus.Logger
+	entry *logrus.Entry
 }
 
-// NewLogger returns a new Logger.
-func NewLogger(out io.Writer, level string) (Logger, error) {
+// NewLogger returns a new Logger writing entries in format, which
+// is FormatText or FormatJSON. Entries are also sent to each of sinks.
+func NewLogger(out io.Writer, level string, format string, sinks ...Sink) (Logger, error) {
 	lvl, err := logrus.ParseLevel(level)
 	if err != nil {
 		return nil, err
 	}
+	var formatter logrus.Formatter
+	switch format {
+	case FormatText, "":
+		formatter = new(logrus.TextFormatter)
+	case FormatJSON:
+		formatter = new(logrus.JSONFormatter)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, must be %s or %s", format, FormatText, FormatJSON)
+	}
+	hooks := make(logrus.LevelHooks)
+	for _, sink := range sinks {
+		hooks.Add(sinkHook{sink: sink})
+	}
 	slogr := &standardLogger{
-		logger: &logrus.Logger{
+		entry: logrus.NewEntry(&logrus.Logger{
 			Out:       out,
-			Formatter: new(logrus.TextFormatter),
-			Hooks:     make(logrus.LevelHooks),
+			Formatter: formatter,
+			Hooks:     hooks,
 			Level:     lvl,
-		},
+		}),
 	}
 
 	return slogr, nil
 }
 
+func (l *standardLogger) WithFields(fields Fields) Logger {
+	return &standardLogger{entry: l.entry.WithFields(logrus.Fields(fields))}
+}
+
+func (l *standardLogger) SetLevel(level string) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.entry.Logger.SetLevel(lvl)
+	return nil
+}
+
+func (l *standardLogger) Level() string {
+	return l.entry.Logger.GetLevel().String()
+}
+
 func (l *standardLogger) Errorf(format string, args ...interface{}) {
-	l.logger.Logf(logrus.ErrorLevel, format, args...)
+	l.entry.Logf(logrus.ErrorLevel, format, args...)
 }
 
 func (l *standardLogger) Infof(format string, args ...interface{}) {
-	l.logger.Logf(logrus.InfoLevel, format, args...)
+	l.entry.Logf(logrus.InfoLevel, format, args...)
 }
 
 func (l *standardLogger) Warnf(format string, args ...interface{}) {
-	l.logger.Logf(logrus.WarnLevel, format, args...)
+	l.entry.Logf(logrus.WarnLevel, format, args...)
 }
 
 func (l *standardLogger) Debugf(format string, args ...interface{}) {
-	l.logger.Logf(logrus.DebugLevel, format, args...)
+	l.entry.Logf(logrus.DebugLevel, format, args...)
 }
 
 func (l *standardLogger) Fatalf(format string, args ...interface{}) {
-	l.logger.Logf(logrus.FatalLevel, format, args...)
+	l.entry.Logf(logrus.FatalLevel, format, args...)
 }
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Sink names accepted by NewSink.
+const (
+	SinkSyslog   = "syslog"
+	SinkJournald = "journald"
+)
+
+// Sink receives every log entry in addition to the writer
+// the Logger was created with.
+type Sink interface {
+	io.Closer
+	send(entry *logrus.Entry) error
+}
+
+// NewSink returns the sink called name, tagging entries with tag.
+func NewSink(name string, tag string) (Sink, error) {
+	switch name {
+	case SinkSyslog:
+		return newSyslogSink(tag)
+	case SinkJournald:
+		return newJournaldSink(tag)
+	}
+	return nil, fmt.Errorf("unknown log sink %q, must be %s or %s", name, SinkSyslog, SinkJournald)
+}
+
+// NewFileWriter returns a writer appending to path. The file is rotated
+// once it reaches maxSizeMB megabytes, keeping at most maxBackups
+// rotated files (0 keeps all of them).
+func NewFileWriter(path string, maxSizeMB int, maxBackups int) io.WriteCloser {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSizeMB,
+		MaxBackups: maxBackups,
+	}
+}
+
+// sinkHook adapts a Sink to a logrus hook.
+type sinkHook struct {
+	sink Sink
+}
+
+func (h sinkHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h sinkHook) Fire(entry *logrus.Entry) error {
+	return h.sink.send(entry)
+}
+
+// formatText returns the message of entry followed by its fields
+// as sorted key=value pairs.
+func formatText(entry *logrus.Entry) string {
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(entry.Message)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, entry.Data[key])
+	}
+	return b.String()
+}
@@ -0,0 +1,43 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"log/syslog"
+
+	"github.com/sirupsen/logrus"
+)
+
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func newSyslogSink(tag string) (Sink, error) {
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) send(entry *logrus.Entry) error {
+	message := formatText(entry)
+	switch entry.Level {
+	case logrus.PanicLevel:
+		return s.writer.Emerg(message)
+	case logrus.FatalLevel:
+		return s.writer.Crit(message)
+	case logrus.ErrorLevel:
+		return s.writer.Err(message)
+	case logrus.WarnLevel:
+		return s.writer.Warning(message)
+	case logrus.InfoLevel:
+		return s.writer.Info(message)
+	default:
+		return s.writer.Debug(message)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/uor-framework/uor-fuse-go/cli/log"
+)
+
+// logLevelOnSignal switches logger to debug on SIGUSR1 and back to
+// level on SIGUSR2 until ctx is done, so a running mount can be
+// diagnosed without remounting.
+func logLevelOnSignal(ctx context.Context, logger log.Logger, level string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(signals)
+	for {
+		var sig os.Signal
+		select {
+		case sig = <-signals:
+		case <-ctx.Done():
+			return
+		}
+		target := level
+		if sig == syscall.SIGUSR1 {
+			target = "debug"
+		}
+		if err := logger.SetLevel(target); err != nil {
+			logger.Errorf("error setting log level: %v", err)
+			continue
+		}
+		logger.Infof("Log level set to %s", target)
+	}
+}
@@ -0,0 +1,11 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/uor-framework/uor-fuse-go/cli/log"
+)
+
+// logLevelOnSignal does nothing on Windows, which has no SIGUSR1 or
+// SIGUSR2.
+func logLevelOnSignal(ctx context.Context, logger log.Logger, level string) {}
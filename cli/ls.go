@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/uor-framework/uor-client-go/util/examples"
+
+	"github.com/uor-framework/uor-fuse-go/cli/log"
+	"github.com/uor-framework/uor-fuse-go/config"
+	"github.com/uor-framework/uor-fuse-go/fs"
+)
+
+var clientLsExamples = []examples.Example{
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "ls localhost:5001/test:latest",
+		Descriptions: []string{
+			"List the top-level files of a collection as a mount would show them.",
+		},
+	},
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "tree --attributes query.yaml -o json localhost:5001/test:latest models",
+		Descriptions: []string{
+			"List every file below models that matches an attribute query, as JSON.",
+		},
+	},
+}
+
+// LsOptions describe configuration options that can
+// be set using the ls subcommand.
+type LsOptions struct {
+	MountOptions
+	Path      string
+	Recursive bool
+	All       bool
+	Output    string
+}
+
+// NewLsCmd creates a new cobra.Command for the ls subcommand. Called as
+// tree, it lists recursively.
+func NewLsCmd(rootOpts *config.RootOptions) *cobra.Command {
+	o := LsOptions{MountOptions: MountOptions{RootOptions: rootOpts}}
+
+	cmd := &cobra.Command{
+		Use:           "ls [flags] SRC|NAME=SRC [PATH]",
+		Aliases:       []string{"tree"},
+		Short:         "List the files of a UOR collection without mounting it",
+		Example:       examples.FormatExamples(clientLsExamples...),
+		SilenceErrors: false,
+		SilenceUsage:  false,
+		Args:          cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.CalledAs() == "tree" {
+				o.Recursive = true
+			}
+			if err := o.Complete(args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run(cmd.Context())
+		},
+	}
+
+	o.addSourceFlags(cmd)
+	cmd.Flags().BoolVarP(&o.Recursive, "recursive", "R", o.Recursive, "list directories recursively")
+	cmd.Flags().BoolVarP(&o.All, "all", "a", o.All, "include names starting with '.', such as .uor metadata and views")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "text", "output format (text, json)")
+
+	return cmd
+}
+
+func (o *LsOptions) Complete(args []string) error {
+	if len(args) < 1 {
+		return errors.New("bug: expecting at least one argument")
+	}
+	o.setSources(args[:1])
+	if len(args) > 1 {
+		o.Path = args[1]
+	}
+	return nil
+}
+
+func (o *LsOptions) Validate() error {
+	if o.Output != "text" && o.Output != "json" {
+		return fmt.Errorf("unknown output format %q, must be text or json", o.Output)
+	}
+	return o.validateSources()
+}
+
+func (o *LsOptions) Run(ctx context.Context) error {
+	if err := o.logToStderr(); err != nil {
+		return err
+	}
+	uorFs, err := o.newUorFs(ctx, nil)
+	if err != nil {
+		return err
+	}
+	infos, err := uorFs.List(o.Path, o.Recursive, o.All)
+	if err != nil {
+		return err
+	}
+
+	if o.Output == "json" {
+		if infos == nil {
+			infos = []fs.FileInfo{}
+		}
+		encoder := json.NewEncoder(o.IOStreams.Out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(infos)
+	}
+
+	w := tabwriter.NewWriter(o.IOStreams.Out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tSIZE\tDIGEST\tMEDIA TYPE\tATTRIBUTES")
+	for _, info := range infos {
+		name, digest := info.Path, info.Digest
+		if info.Dir {
+			name += "/"
+			digest = info.Link
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", name, info.Size, orDash(digest), orDash(info.MediaType), orDash(formatAttributes(info.Attributes)))
+	}
+	return w.Flush()
+}
+
+// logToStderr sends logs to stderr, leaving stdout to the output of
+// commands that print collection content or listings.
+func (o *MountOptions) logToStderr() error {
+	logger, err := log.NewLogger(o.IOStreams.ErrOut, o.LogLevel, o.LogFormat)
+	if err != nil {
+		return err
+	}
+	o.Logger = logger
+	return nil
+}
+
+// formatAttributes formats attributes as key=value pairs sorted by key.
+func formatAttributes(attributes map[string]json.RawMessage) string {
+	pairs := make([]string, 0, len(attributes))
+	for key, value := range attributes {
+		pairs = append(pairs, key+"="+string(value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -1,23 +1,34 @@
 package cli
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/uor-framework/uor-client-go/attributes/matchers"
 	uorclientconfig "github.com/uor-framework/uor-client-go/config"
+	"github.com/uor-framework/uor-client-go/registryclient"
 	"github.com/uor-framework/uor-client-go/registryclient/orasclient"
 	"github.com/uor-framework/uor-client-go/util/examples"
 	"github.com/winfsp/cgofuse/fuse"
+	"sigs.k8s.io/yaml"
 
+	"github.com/uor-framework/uor-fuse-go/cli/log"
 	"github.com/uor-framework/uor-fuse-go/config"
+	"github.com/uor-framework/uor-fuse-go/control"
 	"github.com/uor-framework/uor-fuse-go/fs"
+	"github.com/uor-framework/uor-fuse-go/metrics"
+	"github.com/uor-framework/uor-fuse-go/query"
+	"github.com/uor-framework/uor-fuse-go/tracing"
 )
 
 var clientMountExamples = []examples.Example{
@@ -28,19 +39,61 @@ var clientMountExamples = []examples.Example{
 			"Mount collection reference.",
 		},
 	},
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "mount models=localhost:5001/models:latest data=localhost:5001/data:v1 ./mount-dir/",
+		Descriptions: []string{
+			"Mount several collections as top-level directories.",
+		},
+	},
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "mount --collections collections.txt ./mount-dir/",
+		Descriptions: []string{
+			"Mount the NAME=REFERENCE collections listed in a file. Send SIGHUP to re-read it.",
+		},
+	},
 }
 
 // MountOptions describe configuration options that can
 // be set using the pull subcommand.
 type MountOptions struct {
 	*config.RootOptions
-	Source         string
-	MountPoint     string
-	Insecure       bool
-	PlainHTTP      bool
-	Configs        []string
-	AttributeQuery string
-	NoVerify       bool
+	Source          string
+	MountPoint      string
+	Insecure        bool
+	PlainHTTP       bool
+	Configs         []string
+	AttributeQuery  string
+	NoVerify        bool
+	Where           string
+	LinkDepth       int
+	LinkNameAttr    string
+	Collections     []string
+	CollectionsFile string
+	MetricsAddr     string
+	LogFile         string
+	LogMaxSize      int
+	LogMaxBackups   int
+	LogSink         string
+	TraceEndpoint   string
+	TraceFile       string
+	ControlSocket   string
+	PrefetchAll     bool
+	PrefetchWhere   string
+	PrefetchGlobs   []string
+	PrefetchList    string
+	PrefetchJobs    int
+	RecordProfile   string
+	ReplayProfile   string
+	RefreshInterval time.Duration
+	FuseOptions     []string
+	// IgnoreLoadErrors keeps what could be loaded when a source or
+	// collection fails to load instead of failing.
+	IgnoreLoadErrors bool
+	// QueryViews adds the .query directories in which views are created
+	// with mkdir, which needs a writable mount.
+	QueryViews bool
 }
 
 // NewMountCmd creates a new cobra.Command for the mount subcommand.
@@ -49,39 +102,108 @@ func NewMountCmd(rootOpts *config.RootOptions) *cobra.Command {
 	o := MountOptions{RootOptions: rootOpts}
 
 	cmd := &cobra.Command{
-		Use:           "mount [flags] SRC MOUNTPOINT",
+		Use:           "mount [flags] SRC|NAME=SRC... MOUNTPOINT",
 		Short:         "Mount a UOR collection based on content or attribute address",
 		Example:       examples.FormatExamples(clientMountExamples...),
 		SilenceErrors: false,
 		SilenceUsage:  false,
-		Args:          cobra.ExactArgs(2),
+		Args:          profileArgs(rootOpts, cobra.MinimumNArgs(1)),
 		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				args = o.ProfileArgs
+			}
 			cobra.CheckErr(o.Complete(args))
 			cobra.CheckErr(o.Validate())
 			cobra.CheckErr(o.Run(cmd.Context()))
 		},
 	}
 
+	o.addFlags(cmd)
+
+	return cmd
+}
+
+// addFlags adds the flags of the mount command to cmd.
+func (o *MountOptions) addFlags(cmd *cobra.Command) {
+	o.addSourceFlags(cmd)
+	cmd.Flags().StringVarP(&o.MountPoint, "output", "o", o.MountPoint, "output location for artifacts")
+	cmd.Flags().BoolVarP(&o.NoVerify, "no-verify", "", o.NoVerify, "skip collection signature verification")
+	cmd.Flags().BoolVar(&o.IgnoreLoadErrors, "ignore-load-errors", true, "mount what could be loaded when a reference or collection fails to load instead of failing")
+	cmd.Flags().StringVar(&o.MetricsAddr, "metrics-addr", o.MetricsAddr, "address to serve Prometheus metrics on, e.g. localhost:9090 (disabled if empty)")
+	cmd.Flags().StringVar(&o.LogFile, "log-file", o.LogFile, "write logs to this file instead of stdout")
+	cmd.Flags().IntVar(&o.LogMaxSize, "log-max-size", 100, "size in megabytes at which --log-file is rotated")
+	cmd.Flags().IntVar(&o.LogMaxBackups, "log-max-backups", 3, "number of rotated log files to keep (0 keeps all)")
+	cmd.Flags().StringVar(&o.LogSink, "log-sink", o.LogSink, "also send logs to a system log (syslog, journald)")
+	cmd.Flags().StringVar(&o.TraceEndpoint, "trace-endpoint", o.TraceEndpoint, "OTLP/HTTP collector to send traces to, e.g. http://localhost:4318")
+	cmd.Flags().StringVar(&o.TraceFile, "trace-file", o.TraceFile, "append traces to this file as OTLP/JSON lines")
+	cmd.Flags().StringVar(&o.ControlSocket, "control-socket", o.ControlSocket, "path of the control socket (default ~/.uor/run/HASH.sock for the mountpoint)")
+	cmd.Flags().BoolVar(&o.PrefetchAll, "prefetch-all", o.PrefetchAll, "download every file into the cache in the background after mounting")
+	cmd.Flags().StringVar(&o.PrefetchWhere, "prefetch-where", o.PrefetchWhere, "download files whose attributes match this expression into the cache after mounting")
+	cmd.Flags().StringArrayVar(&o.PrefetchGlobs, "prefetch-glob", o.PrefetchGlobs, "download files whose path in the mount matches this pattern into the cache after mounting")
+	cmd.Flags().StringVar(&o.PrefetchList, "prefetch-list", o.PrefetchList, "path to a file listing paths in the mount to download first, one per line")
+	cmd.Flags().IntVar(&o.PrefetchJobs, "prefetch-jobs", 4, "number of files downloaded in parallel when prefetching")
+	cmd.Flags().StringVar(&o.RecordProfile, "record-profile", o.RecordProfile, "write the path of each file to this prefetch profile when it is first read")
+	cmd.Flags().StringVar(&o.ReplayProfile, "replay-profile", o.ReplayProfile, "prefetch the files in this profile first, in the order they were recorded")
+	cmd.Flags().DurationVar(&o.RefreshInterval, "refresh-interval", o.RefreshInterval, "resolve the mounted references again at this interval, e.g. 10m (disabled if 0)")
+	cmd.Flags().StringArrayVarP(&o.FuseOptions, "fuse-option", "O", o.FuseOptions, "additional FUSE mount option, e.g. allow_other")
+	cmd.Flags().BoolVar(&o.QueryViews, "query-views", o.QueryViews, "add .query directories in which filtered views are created with mkdir (mounts read-write)")
+}
+
+// addSourceFlags adds the flags selecting the registry, collections and
+// files to cmd. They are shared by every command that reads collections.
+func (o *MountOptions) addSourceFlags(cmd *cobra.Command) {
 	cmd.Flags().StringArrayVarP(&o.Configs, "configs", "c", o.Configs, "auth config paths when contacting registries")
 	cmd.Flags().BoolVarP(&o.Insecure, "insecure", "i", o.Insecure, "allow connections to SSL registry without certs")
 	cmd.Flags().BoolVar(&o.PlainHTTP, "plain-http", o.PlainHTTP, "use plain http and not https when contacting registries")
-	cmd.Flags().StringVarP(&o.MountPoint, "output", "o", o.MountPoint, "output location for artifacts")
 	cmd.Flags().StringVar(&o.AttributeQuery, "attributes", o.AttributeQuery, "attribute query config path")
-	cmd.Flags().BoolVarP(&o.NoVerify, "no-verify", "", o.NoVerify, "skip collection signature verification")
-
-	return cmd
+	cmd.Flags().StringVar(&o.Where, "where", o.Where, "attribute query expression, e.g. 'size>1000 AND type=~\"^img\"'")
+	cmd.Flags().IntVar(&o.LinkDepth, "link-depth", 3, "maximum depth of linked collections to expose as subdirectories (0 disables)")
+	cmd.Flags().StringVar(&o.LinkNameAttr, "link-name-attribute", o.LinkNameAttr, "collection attribute used to name linked collection directories instead of the reference")
+	cmd.Flags().StringVar(&o.CollectionsFile, "collections", o.CollectionsFile, "path to a file listing NAME=REFERENCE collections, one per line")
 }
 
 func (o *MountOptions) Complete(args []string) error {
-	if len(args) < 2 {
-		return errors.New("bug: expecting one argument")
+	if len(args) < 1 {
+		return errors.New("bug: expecting at least one argument")
+	}
+	o.MountPoint = args[len(args)-1]
+	if o.ControlSocket == "" {
+		path, err := control.SocketPath(o.MountPoint)
+		if err != nil {
+			return err
+		}
+		o.ControlSocket = path
 	}
-	o.Source = args[0]
-	o.MountPoint = args[1]
+	o.setSources(args[:len(args)-1])
 	return nil
 }
 
+// setSources sets Source from a single SRC argument, or Collections from
+// NAME=SRC arguments.
+func (o *MountOptions) setSources(sources []string) {
+	if len(sources) == 1 && !strings.Contains(sources[0], "=") {
+		o.Source = sources[0]
+		return
+	}
+	o.Collections = sources
+}
+
 func (o *MountOptions) Validate() error {
+	if err := o.validateSources(); err != nil {
+		return err
+	}
+	if o.LogFile != "" && o.LogMaxSize <= 0 {
+		return errors.New("--log-max-size must be positive")
+	}
+	if o.PrefetchJobs < 1 {
+		return errors.New("--prefetch-jobs must be positive")
+	}
+	if o.RefreshInterval < 0 {
+		return errors.New("--refresh-interval must not be negative")
+	}
+	if _, err := o.prefetchOptions(); err != nil {
+		return err
+	}
 	mountPointStat, err := os.Stat(o.MountPoint)
 	if err != nil {
 		return err
@@ -92,42 +214,280 @@ func (o *MountOptions) Validate() error {
 	return nil
 }
 
-func unmountOnInterrupt(host *fuse.FileSystemHost) {
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(
-		interrupt,
-		syscall.SIGINT,
-		syscall.SIGTERM,
-	)
-	<-interrupt
-	host.Unmount()
+// validateSources checks that collections to mount are given once.
+func (o *MountOptions) validateSources() error {
+	if o.Source == "" && len(o.Collections) == 0 && o.CollectionsFile == "" {
+		return errors.New("at least one collection must be specified")
+	}
+	if o.Source != "" && o.CollectionsFile != "" {
+		return errors.New("--collections cannot be combined with a single SRC, use NAME=SRC")
+	}
+	_, err := o.collectionSpecs()
+	return err
 }
 
-func (o *MountOptions) Run(ctx context.Context) error {
+// collectionSpecs returns the collections given as arguments followed
+// by the collections listed in the collections file.
+func (o *MountOptions) collectionSpecs() ([]fs.CollectionSpec, error) {
+	var specs []fs.CollectionSpec
+	for _, arg := range o.Collections {
+		spec, err := fs.ParseCollectionSpec(arg)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	if o.CollectionsFile != "" {
+		fileSpecs, err := readCollectionsFile(o.CollectionsFile)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, fileSpecs...)
+	}
+	return specs, nil
+}
 
-	o.Logger.Infof("Resolving artifacts for reference %s", o.Source)
-	matcher := matchers.PartialAttributeMatcher{}
-	if o.AttributeQuery != "" {
-		query, err := uorclientconfig.ReadAttributeQuery(o.AttributeQuery)
+// readCollectionsFile reads NAME=REFERENCE lines from path. Blank lines
+// and lines starting with '#' are ignored.
+func readCollectionsFile(path string) ([]fs.CollectionSpec, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var specs []fs.CollectionSpec
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		spec, err := fs.ParseCollectionSpec(line)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
 		}
+		specs = append(specs, spec)
+	}
+	return specs, scanner.Err()
+}
 
-		attributeSet, err := uorclientconfig.ConvertToModel(query.Attributes)
+// prefetchOptions returns the files to prefetch after mounting, or
+// nil if no prefetch flag was given.
+func (o *MountOptions) prefetchOptions() (*fs.PrefetchOptions, error) {
+	opts := &fs.PrefetchOptions{
+		All:   o.PrefetchAll,
+		Globs: o.PrefetchGlobs,
+		Jobs:  o.PrefetchJobs,
+	}
+	if o.PrefetchWhere != "" {
+		expr, err := query.Parse(o.PrefetchWhere)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("invalid --prefetch-where: %w", err)
 		}
-		matcher = attributeSet.List()
+		opts.Where = expr
 	}
+	// A replayed profile comes first: it is the order files were needed.
+	for _, list := range []string{o.ReplayProfile, o.PrefetchList} {
+		if list == "" {
+			continue
+		}
+		paths, err := readPrefetchList(list)
+		if err != nil {
+			return nil, err
+		}
+		opts.Paths = append(opts.Paths, paths...)
+	}
+	if !opts.All && opts.Where == nil && len(opts.Globs) == 0 && len(opts.Paths) == 0 {
+		return nil, nil
+	}
+	return opts, nil
+}
 
-	if !o.NoVerify {
-		o.Logger.Infof("Checking signature of %s", o.Source)
-		//if err := verifyCollection(o, ctx); err != nil {
-		//	return err
-		//}
+// readPrefetchList reads paths in the mount from path, one per line, in
+// the format written by --record-profile. Blank lines and lines starting
+// with '#' are ignored.
+func readPrefetchList(path string) ([]string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
 
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
 	}
+	return paths, scanner.Err()
+}
+
+// reloadOnHangup re-reads the attribute query and the mounted collections
+// each time SIGHUP is received. A changed attribute query rebuilds the
+// whole tree with a new client.
+func (o *MountOptions) reloadOnHangup(ctx context.Context, uorFs *fs.UorFs) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-hangup:
+		case <-ctx.Done():
+			return
+		}
+		if o.AttributeQuery != "" {
+			o.Logger.Infof("Reloading attribute query %s", o.AttributeQuery)
+			if err := o.reloadFilter(uorFs); err != nil {
+				o.Logger.Errorf("error reloading attribute query: %v", err)
+			}
+		}
 
+		if o.Source != "" {
+			continue
+		}
+		o.Logger.Infof("Reloading collections")
+		specs, err := o.collectionSpecs()
+		if err != nil {
+			o.Logger.Errorf("error reading collections: %v", err)
+			continue
+		}
+		if err := uorFs.SetCollections(specs); err != nil {
+			o.Logger.Errorf("error reloading collections: %v", err)
+		}
+	}
+}
+
+// reloadFilter applies the attribute query file to a running mount
+// if it changed.
+func (o *MountOptions) reloadFilter(uorFs *fs.UorFs) error {
+	matcher, err := o.readMatcher()
+	if err != nil {
+		return err
+	}
+	if query.Format(matcher) == query.Format(uorFs.Matcher()) {
+		o.Logger.Infof("Attribute query unchanged")
+		return nil
+	}
+	client, err := o.newClient(matcher)
+	if err != nil {
+		return err
+	}
+	return uorFs.SetFilter(client, matcher)
+}
+
+// readMatcher combines the attribute query file, if any, with the
+// --where expression. It returns nil when nothing is filtered.
+func (o *MountOptions) readMatcher() (query.Expression, error) {
+	var matcher query.Expression
+	if o.AttributeQuery != "" {
+		fileQuery, err := readAttributeQuery(o.AttributeQuery)
+		if err != nil {
+			return nil, err
+		}
+		matcher = fileQuery
+	}
+	if o.Where != "" {
+		where, err := query.Parse(o.Where)
+		if err != nil {
+			return nil, fmt.Errorf("--where: %w", err)
+		}
+		matcher = query.And(matcher, where)
+	}
+	return matcher, nil
+}
+
+// readAttributeQuery reads an AttributeQuery config. In addition to exact
+// attributes the config may hold a query expression in a "where" field.
+func readAttributeQuery(path string) (query.Expression, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var where query.Expression
+	if raw, ok := fields["where"]; ok {
+		var expr string
+		if err := json.Unmarshal(raw, &expr); err != nil {
+			return nil, fmt.Errorf("%s: where: %w", path, err)
+		}
+		if where, err = query.Parse(expr); err != nil {
+			return nil, fmt.Errorf("%s: where: %w", path, err)
+		}
+		delete(fields, "where")
+		if data, err = json.Marshal(fields); err != nil {
+			return nil, err
+		}
+	}
+
+	attributeQuery, err := uorclientconfig.LoadAttributeQuery(data)
+	if err != nil {
+		return nil, err
+	}
+	attributeSet, err := uorclientconfig.ConvertToModel(attributeQuery.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	return query.And(query.FromAttributes(attributeSet.List()), where), nil
+}
+
+// setupLogging replaces the root logger when this mount logs to a file
+// or system log. The returned function closes the log outputs.
+func (o *MountOptions) setupLogging(fields log.Fields) (func(), error) {
+	if o.LogFile == "" && o.LogSink == "" {
+		return func() {}, nil
+	}
+
+	var closers []io.Closer
+	closeAll := func() {
+		for _, closer := range closers {
+			closer.Close()
+		}
+	}
+	out := o.IOStreams.Out
+	if o.LogFile != "" {
+		file := log.NewFileWriter(o.LogFile, o.LogMaxSize, o.LogMaxBackups)
+		closers = append(closers, file)
+		out = file
+	}
+	var sinks []log.Sink
+	if o.LogSink != "" {
+		sink, err := log.NewSink(o.LogSink, filepath.Base(os.Args[0]))
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, sink)
+		sinks = append(sinks, sink)
+	}
+	logger, err := log.NewLogger(out, o.LogLevel, o.LogFormat, sinks...)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	if fields != nil {
+		logger = logger.WithFields(fields)
+	}
+	previous := o.Logger
+	o.Logger = logger
+	return func() {
+		o.Logger = previous
+		closeAll()
+	}, nil
+}
+
+// newClient returns a registry client that pulls blobs matching matcher.
+func (o *MountOptions) newClient(matcher query.Expression) (registryclient.Client, error) {
 	client, err := orasclient.NewClient(
 		orasclient.SkipTLSVerify(o.Insecure),
 		orasclient.WithAuthConfigs(o.Configs),
@@ -135,22 +495,166 @@ func (o *MountOptions) Run(ctx context.Context) error {
 		orasclient.WithPullableAttributes(matcher),
 	)
 	if err != nil {
-		return fmt.Errorf("error configuring client: %v", err)
+		return nil, fmt.Errorf("error configuring client: %v", err)
 	}
+	return client, nil
+}
 
-	fuseHost := fuse.NewFileSystemHost(fs.NewUorFs(ctx, fs.UorFsOptions(*o), client, matcher))
+// newUorFs builds the tree of the collections selected by o without
+// mounting it. Metrics are recorded in m, which may be nil.
+func (o *MountOptions) newUorFs(ctx context.Context, m *metrics.Metrics) (*fs.UorFs, error) {
+	matcher, err := o.readMatcher()
+	if err != nil {
+		return nil, err
+	}
+	client, err := o.newClient(matcher)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := o.collectionSpecs()
+	if err != nil {
+		return nil, err
+	}
+	fsOpts := fs.UorFsOptions(*o)
+	fsOpts.Collections = nil
+	for _, spec := range specs {
+		fsOpts.Collections = append(fsOpts.Collections, spec.Name+"="+spec.Reference)
+	}
+	return fs.NewUorFs(ctx, fsOpts, client, matcher, m)
+}
+
+// profileArgs returns a cobra.PositionalArgs that accepts no arguments
+// when a configuration profile is selected, which supplies them, and
+// otherwise validates args with validate.
+func profileArgs(rootOpts *config.RootOptions, validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && (rootOpts.Profile != "" || rootOpts.UOR_PROFILE != "") {
+			return nil
+		}
+		return validate(cmd, args)
+	}
+}
+
+func (o *MountOptions) Run(ctx context.Context) error {
+	shutdownTracing, err := tracing.Setup(o.TraceEndpoint, o.TraceFile, o.MountPoint)
+	if err != nil {
+		return fmt.Errorf("error configuring tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			o.Logger.Errorf("error flushing traces: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return o.mount(ctx, false)
+}
+
+// mount builds the tree of o and serves it at MountPoint until ctx is
+// done, which unmounts it, or it is unmounted from outside. Logs go to
+// the log file and sink of o while it is mounted. Tracing is set up for
+// the whole process by the caller.
+//
+// A supervised mount is one of the mounts of serve: its logs carry its
+// mount point and SIGHUP is left to the supervisor. Otherwise SIGHUP
+// reloads its attribute query and collections.
+func (o *MountOptions) mount(ctx context.Context, supervised bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var fields log.Fields
+	if supervised {
+		fields = log.Fields{"mountpoint": o.MountPoint}
+	}
+	closeLog, err := o.setupLogging(fields)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	if !supervised || o.LogFile != "" || o.LogSink != "" {
+		go logLevelOnSignal(ctx, o.Logger, o.LogLevel)
+	}
+
+	if o.Source != "" {
+		o.Logger.Infof("Resolving artifacts for reference %s", o.Source)
+	}
+	if !o.NoVerify {
+		o.Logger.Infof("Checking signature of %s", o.Source)
+		//if err := verifyCollection(o, ctx); err != nil {
+		//	return err
+		//}
+
+	}
+
+	var m *metrics.Metrics
+	if o.MetricsAddr != "" {
+		m = metrics.New(o.MountPoint)
+		go func() {
+			o.Logger.Infof("Serving metrics on %s/metrics", o.MetricsAddr)
+			if err := m.Serve(ctx, o.MetricsAddr); err != nil {
+				o.Logger.Errorf("error serving metrics: %v", err)
+			}
+		}()
+	}
+	// Read before NewUorFs, which truncates a profile being re-recorded.
+	prefetch, err := o.prefetchOptions()
+	if err != nil {
+		return err
+	}
+	uorFs, err := o.newUorFs(ctx, m)
+	if err != nil {
+		return err
+	}
+	if prefetch != nil {
+		go func() {
+			if err := uorFs.PrefetchFiles(ctx, *prefetch); err != nil {
+				o.Logger.Errorf("error prefetching: %v", err)
+			}
+		}()
+	}
+	fuseHost := fuse.NewFileSystemHost(uorFs)
 	fuseHost.SetCapReaddirPlus(true)
-	go unmountOnInterrupt(fuseHost)
+	if !supervised {
+		go o.reloadOnHangup(ctx, uorFs)
+	}
+	controller := &mountController{o: o, uorFs: uorFs}
+	if o.RefreshInterval > 0 {
+		go controller.refreshEvery(ctx, o.RefreshInterval)
+	}
+	go func() {
+		o.Logger.Infof("Serving control socket %s", o.ControlSocket)
+		if err := control.Serve(ctx, o.ControlSocket, controller, o.Logger); err != nil {
+			o.Logger.Warnf("control socket unavailable: %v", err)
+		}
+	}()
 	o.Logger.Infof("Mounting UOR to directory %v", o.MountPoint)
 	opts := []string{
 		"-o", "fsname=uorfs",
-		"-o", "ro",
 		"-o", "default_permissions",
 		"-o", "auto_unmount",
 		//"-o", "user_xattr",
 	}
-	mounted := fuseHost.Mount(o.MountPoint, opts)
-	o.Logger.Infof("%v", mounted)
-
+	// Views are created with mkdir in .query directories, so the mount
+	// is only writable with them. UorFs refuses every other modification.
+	if !o.QueryViews {
+		opts = append(opts, "-o", "ro")
+	}
+	for _, option := range o.FuseOptions {
+		opts = append(opts, "-o", option)
+	}
+	unmounted := make(chan struct{})
+	defer close(unmounted)
+	go func() {
+		select {
+		case <-ctx.Done():
+			fuseHost.Unmount()
+		case <-unmounted:
+		}
+	}()
+	if !fuseHost.Mount(o.MountPoint, opts) {
+		return fmt.Errorf("mounting %s failed", o.MountPoint)
+	}
+	o.Logger.Infof("Unmounted %s", o.MountPoint)
 	return nil
 }
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/uor-framework/uor-client-go/util/examples"
+
+	"github.com/uor-framework/uor-fuse-go/config"
+	"github.com/uor-framework/uor-fuse-go/fs"
+)
+
+var clientPullExamples = []examples.Example{
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "pull localhost:5001/test:latest ./out-dir/",
+		Descriptions: []string{
+			"Write the files of a collection to a directory without mounting it.",
+		},
+	},
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "pull --where 'type=\"model\"' models=localhost:5001/models:latest ./out-dir/",
+		Descriptions: []string{
+			"Write the matching files of a collection to a subdirectory.",
+		},
+	},
+}
+
+// PullOptions describe configuration options that can
+// be set using the pull subcommand.
+type PullOptions struct {
+	MountOptions
+	Jobs     int
+	NoXattrs bool
+}
+
+// NewPullCmd creates a new cobra.Command for the pull subcommand.
+func NewPullCmd(rootOpts *config.RootOptions) *cobra.Command {
+	o := PullOptions{MountOptions: MountOptions{RootOptions: rootOpts}}
+
+	cmd := &cobra.Command{
+		Use:           "pull [flags] SRC|NAME=SRC... DIR",
+		Aliases:       []string{"export"},
+		Short:         "Write a UOR collection to a directory without mounting it",
+		Example:       examples.FormatExamples(clientPullExamples...),
+		SilenceErrors: false,
+		SilenceUsage:  false,
+		Args:          cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run(cmd.Context())
+		},
+	}
+
+	o.addSourceFlags(cmd)
+	cmd.Flags().IntVarP(&o.Jobs, "jobs", "j", 4, "number of files downloaded in parallel")
+	cmd.Flags().BoolVar(&o.NoXattrs, "no-xattrs", o.NoXattrs, "do not set user.uor.* extended attributes on written files")
+
+	return cmd
+}
+
+func (o *PullOptions) Complete(args []string) error {
+	if len(args) < 1 {
+		return errors.New("bug: expecting at least one argument")
+	}
+	o.MountPoint = args[len(args)-1]
+	o.setSources(args[:len(args)-1])
+	return nil
+}
+
+func (o *PullOptions) Validate() error {
+	if err := o.validateSources(); err != nil {
+		return err
+	}
+	if o.Jobs < 1 {
+		return errors.New("--jobs must be positive")
+	}
+	info, err := os.Stat(o.MountPoint)
+	if err == nil && !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", o.MountPoint)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (o *PullOptions) Run(ctx context.Context) error {
+	if o.Source != "" {
+		o.Logger.Infof("Resolving artifacts for reference %s", o.Source)
+	}
+	uorFs, err := o.newUorFs(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(o.MountPoint, 0755); err != nil {
+		return err
+	}
+
+	result, err := uorFs.Export(ctx, o.MountPoint, fs.ExportOptions{
+		Jobs:   o.Jobs,
+		Xattrs: !o.NoXattrs,
+	})
+	o.Logger.Infof("Wrote %d files (%d bytes) and %d directories to %s", result.Files, result.Bytes, result.Dirs, o.MountPoint)
+	return err
+}
@@ -0,0 +1,411 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/uor-framework/uor-client-go/util/examples"
+
+	"github.com/uor-framework/uor-fuse-go/cli/log"
+	"github.com/uor-framework/uor-fuse-go/config"
+	"github.com/uor-framework/uor-fuse-go/tracing"
+)
+
+var clientServeExamples = []examples.Example{
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "serve --config mounts.yaml",
+		Descriptions: []string{
+			"Mount every entry of the mounts list in the configuration file and keep them mounted.",
+		},
+	},
+}
+
+// Bounds of the delay before remounting a failed mount. The delay
+// doubles after each failure and is reset once a mount has stayed up
+// for maxRemountDelay.
+const (
+	minRemountDelay = time.Second
+	maxRemountDelay = time.Minute
+)
+
+// unmountTimeout is how long reconcile waits for the mounts it cancels
+// to be unmounted.
+const unmountTimeout = 10 * time.Second
+
+// processFlags are the mount flags that configure the whole process.
+// Under serve they are flags of serve and cannot be set per mount.
+var processFlags = []string{"trace-endpoint", "trace-file"}
+
+// ServeOptions describe configuration options that can
+// be set using the serve subcommand.
+type ServeOptions struct {
+	*config.RootOptions
+	PollInterval  time.Duration
+	TraceEndpoint string
+	TraceFile     string
+	// root is the root command, which reloaded files are checked against.
+	root *cobra.Command
+}
+
+// NewServeCmd creates a new cobra.Command for the serve subcommand.
+func NewServeCmd(rootOpts *config.RootOptions) *cobra.Command {
+	o := ServeOptions{RootOptions: rootOpts}
+
+	cmd := &cobra.Command{
+		Use:           "serve [flags]",
+		Short:         "Serve the mounts listed in the configuration file",
+		Example:       examples.FormatExamples(clientServeExamples...),
+		SilenceErrors: false,
+		SilenceUsage:  false,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			o.root = cmd.Root()
+			return o.Run(cmd.Context())
+		},
+	}
+
+	cmd.Flags().DurationVar(&o.PollInterval, "poll-interval", 5*time.Second, "how often the configuration file is checked for changes (0 disables, SIGHUP still reloads)")
+	cmd.Flags().StringVar(&o.TraceEndpoint, "trace-endpoint", o.TraceEndpoint, "OTLP/HTTP collector to send the traces of every mount to, e.g. http://localhost:4318")
+	cmd.Flags().StringVar(&o.TraceFile, "trace-file", o.TraceFile, "append the traces of every mount to this file as OTLP/JSON lines")
+
+	return cmd
+}
+
+func (o *ServeOptions) Validate() error {
+	if o.File == nil {
+		return errors.New("serve needs a configuration file with a mounts list, see --config")
+	}
+	if o.PollInterval < 0 {
+		return errors.New("--poll-interval must not be negative")
+	}
+	return nil
+}
+
+func (o *ServeOptions) Run(ctx context.Context) error {
+	shutdownTracing, err := tracing.Setup(o.TraceEndpoint, o.TraceFile, "")
+	if err != nil {
+		return fmt.Errorf("error configuring tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			o.Logger.Errorf("error flushing traces: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go logLevelOnSignal(ctx, o.Logger, o.LogLevel)
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	var poll <-chan time.Time
+	if o.PollInterval > 0 {
+		ticker := time.NewTicker(o.PollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	s := &supervisor{o: o, running: map[string]*supervisedMount{}, stopping: map[string]*supervisedMount{}}
+	path := o.File.Path
+	modified := modTime(path)
+	s.reconcile(ctx, o.File)
+	for {
+		select {
+		case <-ctx.Done():
+			o.Logger.Infof("Unmounting %d mounts", len(s.running))
+			s.reconcile(ctx, &config.File{Path: path})
+			return nil
+		case <-hangup:
+		case <-poll:
+			if mod := modTime(path); mod.Equal(modified) {
+				if s.stopped() {
+					s.reconcile(ctx, s.file)
+				}
+				continue
+			}
+		}
+		modified = modTime(path)
+		o.Logger.Infof("Reloading %s", path)
+		file, err := config.ReadFile(path)
+		if err == nil {
+			err = file.Check(o.root)
+		}
+		if err != nil {
+			o.Logger.Errorf("error reloading configuration, keeping current mounts: %v", err)
+			continue
+		}
+		s.reconcile(ctx, file)
+	}
+}
+
+// modTime returns the modification time of path, or the zero time if it
+// cannot be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// supervisor keeps the mounts of a configuration file mounted.
+type supervisor struct {
+	o *ServeOptions
+	// running maps mount points to their mounts.
+	running map[string]*supervisedMount
+	// stopping maps mount points to the mounts cancelled by reconcile that
+	// are not unmounted yet.
+	stopping map[string]*supervisedMount
+	// file is the configuration last reconciled.
+	file *config.File
+}
+
+// supervisedMount is a mount kept mounted until cancel is called. done
+// is closed once it is unmounted.
+type supervisedMount struct {
+	// options are the options the mount was started with.
+	options MountOptions
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// reconcile unmounts the running mounts that are no longer listed in
+// file or whose options changed, then mounts the entries that are not
+// running. Options are compared once resolved, so changing the defaults
+// of the mount command remounts the mounts they apply to. Mount points
+// whose previous mount is still busy are mounted by a later reconcile.
+func (s *supervisor) reconcile(ctx context.Context, file *config.File) {
+	s.file = file
+	desired := map[string]*MountOptions{}
+	for _, mount := range file.Mounts {
+		o, err := s.mountOptions(file, mount)
+		if err != nil {
+			s.o.Logger.Errorf("not mounting %s: %v", mount.MountPoint(), err)
+			continue
+		}
+		desired[mount.MountPoint()] = o
+	}
+	s.rejectShared(desired)
+
+	for mountPoint, running := range s.running {
+		if o, ok := desired[mountPoint]; ok && sameOptions(*o, running.options) {
+			continue
+		}
+		s.o.Logger.Infof("Unmounting %s", mountPoint)
+		running.cancel()
+		s.stopping[mountPoint] = running
+		delete(s.running, mountPoint)
+	}
+	s.waitStopping()
+
+	mountPoints := make([]string, 0, len(desired))
+	for mountPoint := range desired {
+		mountPoints = append(mountPoints, mountPoint)
+	}
+	sort.Strings(mountPoints)
+	for _, mountPoint := range mountPoints {
+		if _, ok := s.running[mountPoint]; ok || ctx.Err() != nil {
+			continue
+		}
+		if _, ok := s.stopping[mountPoint]; ok {
+			s.o.Logger.Errorf("not mounting %s: the previous mount is still busy", mountPoint)
+			continue
+		}
+		o := desired[mountPoint]
+		mountCtx, cancel := context.WithCancel(ctx)
+		running := &supervisedMount{options: *o, cancel: cancel, done: make(chan struct{})}
+		s.running[mountPoint] = running
+		go func() {
+			defer close(running.done)
+			keepMounted(mountCtx, o)
+		}()
+	}
+}
+
+// waitStopping waits up to unmountTimeout for the stopping mounts to be
+// unmounted. Those still mounted, typically because a file is open, are
+// logged and kept in stopping, so that their mount point is not mounted
+// again until they are gone.
+func (s *supervisor) waitStopping() {
+	deadline := time.NewTimer(unmountTimeout)
+	defer deadline.Stop()
+	expired := false
+	for mountPoint, stopping := range s.stopping {
+		if !expired {
+			select {
+			case <-stopping.done:
+			case <-deadline.C:
+				expired = true
+			}
+		}
+		select {
+		case <-stopping.done:
+			delete(s.stopping, mountPoint)
+		default:
+			s.o.Logger.Errorf("%s did not unmount within %s, it may be busy", mountPoint, unmountTimeout)
+		}
+	}
+}
+
+// stopped removes from stopping the mounts that have been unmounted
+// since and reports whether there were any.
+func (s *supervisor) stopped() bool {
+	found := false
+	for mountPoint, stopping := range s.stopping {
+		select {
+		case <-stopping.done:
+			delete(s.stopping, mountPoint)
+			found = true
+		default:
+		}
+	}
+	return found
+}
+
+// exclusiveFlags are the mount flags no two mounts may share: each
+// mount serves metrics on its own address, rotates its own log file and
+// listens on its own control socket.
+var exclusiveFlags = []struct {
+	name  string
+	value func(*MountOptions) string
+}{
+	{"metrics-addr", func(o *MountOptions) string { return o.MetricsAddr }},
+	{"log-file", func(o *MountOptions) string { return o.LogFile }},
+	{"control-socket", func(o *MountOptions) string { return o.ControlSocket }},
+}
+
+// rejectShared removes from desired, logging why, the mounts that share
+// the value of one of exclusiveFlags, such as a metrics-addr set in the
+// mount section.
+func (s *supervisor) rejectShared(desired map[string]*MountOptions) {
+	for _, flag := range exclusiveFlags {
+		users := map[string][]string{}
+		for mountPoint, o := range desired {
+			if value := flag.value(o); value != "" {
+				users[value] = append(users[value], mountPoint)
+			}
+		}
+		for value, mountPoints := range users {
+			if len(mountPoints) < 2 {
+				continue
+			}
+			sort.Strings(mountPoints)
+			for _, mountPoint := range mountPoints {
+				s.o.Logger.Errorf("not mounting %s: %s %s is shared by %s, set it per mount", mountPoint, flag.name, value, strings.Join(mountPoints, ", "))
+				delete(desired, mountPoint)
+			}
+		}
+	}
+}
+
+// sameOptions reports whether a and b mount the same collections the
+// same way, ignoring their root options, which serve shares.
+func sameOptions(a, b MountOptions) bool {
+	a.RootOptions, b.RootOptions = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// mountOptions returns the validated options of the mount command for
+// mount: the defaults of the mount command in file, overridden by the
+// environment, overridden by the flags of the entry. Logs carry the
+// mount point.
+// processFlags set for the mount command or the entry are rejected;
+// top-level values of those flags apply to serve itself.
+func (s *supervisor) mountOptions(file *config.File, mount config.Mount) (*MountOptions, error) {
+	section, _ := file.Values["mount"].(map[string]interface{})
+	for _, name := range processFlags {
+		_, inSection := section[name]
+		if _, inEntry := mount.Values[name]; inSection || inEntry {
+			return nil, fmt.Errorf("%s cannot be set per mount, set it for serve", name)
+		}
+	}
+
+	rootOpts := *s.o.RootOptions
+	rootOpts.Logger = s.o.Logger.WithFields(log.Fields{"mountpoint": mount.MountPoint()})
+	o := &MountOptions{RootOptions: &rootOpts}
+	cmd := &cobra.Command{Use: "mount"}
+	o.addFlags(cmd)
+
+	flags := cmd.Flags()
+	for _, defaults := range []map[string]interface{}{file.SectionValues("mount"), s.o.EnvConfig.FlagValues()} {
+		for name, value := range defaults {
+			if flags.Lookup(name) == nil || isProcessFlag(name) {
+				continue
+			}
+			if err := config.SetFlag(flags, name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name, value := range mount.Values {
+		if err := config.SetFlag(flags, name, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := o.Complete(mount.Args); err != nil {
+		return nil, err
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func isProcessFlag(name string) bool {
+	for _, flag := range processFlags {
+		if name == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// keepMounted mounts o until ctx is done, mounting it again with a
+// growing delay when it fails or is unmounted from outside. o is
+// validated again before each attempt, as the mount point may have
+// been removed since.
+func keepMounted(ctx context.Context, o *MountOptions) {
+	delay := minRemountDelay
+	for {
+		start := time.Now()
+		err := o.Validate()
+		if err == nil {
+			err = o.mount(ctx, true)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			o.Logger.Errorf("mount failed, retrying in %s: %v", delay, err)
+		} else {
+			o.Logger.Warnf("%s was unmounted, remounting in %s", o.MountPoint, delay)
+		}
+
+		if time.Since(start) > maxRemountDelay {
+			delay = minRemountDelay
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		if delay *= 2; delay > maxRemountDelay {
+			delay = maxRemountDelay
+		}
+	}
+}
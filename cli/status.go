@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/uor-framework/uor-client-go/util/examples"
+
+	"github.com/uor-framework/uor-fuse-go/config"
+	"github.com/uor-framework/uor-fuse-go/control"
+)
+
+var clientStatusExamples = []examples.Example{
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "status ./mount-dir/",
+		Descriptions: []string{
+			"Show the state of a running mount.",
+		},
+	},
+	{
+		RootCommand:   filepath.Base(os.Args[0]),
+		CommandString: "status -o json ./mount-dir/",
+		Descriptions: []string{
+			"Show the state of a running mount as JSON.",
+		},
+	},
+}
+
+var statusTemplate = `Mount point:	{{ .MountPoint }} (pid {{ .PID }})
+{{- if .Source }}
+Reference:	{{ .Source }}
+Digest:	{{ .Digest }}
+{{- end }}
+{{- range .Collections }}
+Collection:	{{ .Name }}={{ .Reference }}
+ Digest:	{{ .Digest }}
+{{- end }}
+{{- if .Filter }}
+Filter:	{{ .Filter }}
+{{- end }}
+Last refresh:	{{ .LastRefresh.Format "2006-01-02T15:04:05Z07:00" }} ({{ since .LastRefresh }} ago)
+Nodes:	{{ .Nodes }} ({{ .Files }} files)
+Open files:	{{ .OpenFiles }}
+Cache:	{{ .CachedFiles }} files, {{ .CacheBytes }} bytes
+Cache hits:	{{ .CacheHits }}/{{ add .CacheHits .CacheMisses }} ({{ printf "%.1f" (percent .CacheHitRatio) }}%)
+In-flight fetches:	{{ .Fetching }}
+{{- with .Prefetch }}{{ if .Total }}
+Prefetch:	{{ .Done }}/{{ .Total }} files, {{ .Bytes }} bytes, {{ .Failed }} failed{{ if .Running }} (running){{ end }}
+{{- end }}{{ end }}
+Log level:	{{ .LogLevel }}
+`
+
+// StatusOptions describe configuration options that can
+// be set using the status subcommand.
+type StatusOptions struct {
+	*config.RootOptions
+	MountPoint    string
+	ControlSocket string
+	Output        string
+}
+
+// NewStatusCmd creates a new cobra.Command for the status subcommand.
+func NewStatusCmd(rootOpts *config.RootOptions) *cobra.Command {
+	o := StatusOptions{RootOptions: rootOpts}
+
+	cmd := &cobra.Command{
+		Use:           "status [flags] MOUNTPOINT",
+		Short:         "Show the state of a running mount",
+		Example:       examples.FormatExamples(clientStatusExamples...),
+		SilenceErrors: false,
+		SilenceUsage:  false,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "text", "output format (text, json)")
+	cmd.Flags().StringVar(&o.ControlSocket, "control-socket", o.ControlSocket, "path of the mount's control socket (default derived from MOUNTPOINT)")
+
+	return cmd
+}
+
+func (o *StatusOptions) Complete(args []string) error {
+	if len(args) < 1 {
+		return errors.New("bug: expecting one argument")
+	}
+	o.MountPoint = args[0]
+	if o.ControlSocket == "" {
+		path, err := control.SocketPath(o.MountPoint)
+		if err != nil {
+			return err
+		}
+		o.ControlSocket = path
+	}
+	return nil
+}
+
+func (o *StatusOptions) Validate() error {
+	if o.Output != "text" && o.Output != "json" {
+		return fmt.Errorf("unknown output format %q, must be text or json", o.Output)
+	}
+	return nil
+}
+
+func (o *StatusOptions) Run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	resp, err := control.Call(ctx, o.ControlSocket, control.Request{Command: control.CommandStatus})
+	if err != nil {
+		return fmt.Errorf("%s: %w (is it mounted?)", o.MountPoint, err)
+	}
+	if resp.Status == nil {
+		return errors.New("mount returned no status")
+	}
+
+	if o.Output == "json" {
+		encoder := json.NewEncoder(o.IOStreams.Out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			*control.Status
+			CacheHitRatio float64 `json:"cacheHitRatio"`
+		}{resp.Status, resp.Status.CacheHitRatio()})
+	}
+
+	tmp, err := template.New("status").Funcs(template.FuncMap{
+		"add":     func(a, b int64) int64 { return a + b },
+		"percent": func(ratio float64) float64 { return ratio * 100 },
+		"since":   func(t time.Time) time.Duration { return time.Since(t).Round(time.Second) },
+	}).Parse(statusTemplate)
+	if err != nil {
+		return fmt.Errorf("template parsing error: %v", err)
+	}
+	w := tabwriter.NewWriter(o.IOStreams.Out, 0, 8, 1, ' ', 0)
+	if err := tmp.Execute(w, resp.Status); err != nil {
+		return err
+	}
+	return w.Flush()
+}
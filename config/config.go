@@ -12,7 +12,15 @@ import (
 // EnvConfig stores CLI runtime configuration from environment variables.
 // Struct field names should match the name of the environment variable that the field is derived from.
 type EnvConfig struct {
-	UOR_DEV_MODE bool // true: show unimplemented stubs in --help
+	UOR_DEV_MODE   bool   // true: show unimplemented stubs in --help
+	UOR_CONFIG     string // configuration file, instead of ~/.uor/fuse.yaml
+	UOR_PROFILE    string // profile of the configuration file to apply
+	UOR_CACHE      string // --cache-dir
+	UOR_CACHE_SIZE string // --cache-max-size
+	UOR_LOGLEVEL   string // --loglevel
+	UOR_LOG_FORMAT string // --log-format
+	UOR_INSECURE   string // --insecure
+	UOR_PLAIN_HTTP string // --plain-http
 }
 
 func ReadEnvConfig() EnvConfig {
@@ -22,14 +30,53 @@ func ReadEnvConfig() EnvConfig {
 	devMode, err := strconv.ParseBool(devModeString)
 	envConfig.UOR_DEV_MODE = err == nil && devMode
 
+	envConfig.UOR_CONFIG = os.Getenv("UOR_CONFIG")
+	envConfig.UOR_PROFILE = os.Getenv("UOR_PROFILE")
+	envConfig.UOR_CACHE = os.Getenv("UOR_CACHE")
+	envConfig.UOR_CACHE_SIZE = os.Getenv("UOR_CACHE_SIZE")
+	envConfig.UOR_LOGLEVEL = os.Getenv("UOR_LOGLEVEL")
+	envConfig.UOR_LOG_FORMAT = os.Getenv("UOR_LOG_FORMAT")
+	envConfig.UOR_INSECURE = os.Getenv("UOR_INSECURE")
+	envConfig.UOR_PLAIN_HTTP = os.Getenv("UOR_PLAIN_HTTP")
+
 	return envConfig
 }
 
+// FlagValues returns the flag values set by environment variables.
+func (e EnvConfig) FlagValues() map[string]interface{} {
+	values := map[string]interface{}{}
+	for name, value := range map[string]string{
+		"cache-dir":      e.UOR_CACHE,
+		"cache-max-size": e.UOR_CACHE_SIZE,
+		"loglevel":       e.UOR_LOGLEVEL,
+		"log-format":     e.UOR_LOG_FORMAT,
+		"insecure":       e.UOR_INSECURE,
+		"plain-http":     e.UOR_PLAIN_HTTP,
+	} {
+		if value != "" {
+			values[name] = value
+		}
+	}
+	return values
+}
+
 // RootOptions describe global configuration options that can be set.
 type RootOptions struct {
 	IOStreams genericclioptions.IOStreams
 	LogLevel  string
+	LogFormat string
 	Logger    log.Logger
 	CacheDir  string
+	// CacheMaxSize is the size, e.g. 10G, mounts keep the blob cache
+	// under. Empty or 0 means no limit.
+	CacheMaxSize string
+	// ConfigFile and Profile select the configuration file and profile
+	// applied by ApplyConfig.
+	ConfigFile string
+	Profile    string
+	// File is the configuration file read, or nil if there is none.
+	File *File
+	// ProfileArgs are the arguments of the selected profile.
+	ProfileArgs []string
 	EnvConfig
 }
@@ -0,0 +1,425 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+// profilesKey holds the named profiles in a configuration file.
+const profilesKey = "profiles"
+
+// mountsKey holds the mounts served by the serve command.
+const mountsKey = "mounts"
+
+// argsKey holds the arguments of a profile or mount.
+const argsKey = "args"
+
+// File is a configuration file. Keys are flag names and set the default
+// of those flags for every command that has them. A key naming a
+// subcommand holds a section with defaults for that command only, and
+// may itself hold sections for its subcommands:
+//
+//	insecure: true
+//	mount:
+//	  prefetch-jobs: 8
+//	cache:
+//	  prune:
+//	    max-size: 10G
+//	profiles:
+//	  models:
+//	    args: [models=localhost:5001/models:latest, /mnt/models]
+//	    where: 'type="model"'
+//	mounts:
+//	  - reference: localhost:5001/data:latest
+//	    mountpoint: /mnt/data
+//	    refresh-interval: 10m
+//
+// Profiles are named sets of flags, with the arguments of the command in
+// args, selected with --profile. Mounts are served by the serve command.
+type File struct {
+	Path     string
+	Values   map[string]interface{}
+	Profiles map[string]Profile
+	Mounts   []Mount
+}
+
+// Mount is a mount served by the serve command: the arguments of the
+// mount command and the values of its flags.
+type Mount struct {
+	Args   []string
+	Values map[string]interface{}
+}
+
+// MountPoint returns the mount point of m, its last argument.
+func (m Mount) MountPoint() string {
+	if len(m.Args) == 0 {
+		return ""
+	}
+	return m.Args[len(m.Args)-1]
+}
+
+// Profile is a named set of flag values and arguments.
+type Profile struct {
+	Name   string
+	Args   []string
+	Values map[string]interface{}
+}
+
+// DefaultFile returns the configuration file read when none is given,
+// ~/.uor/fuse.yaml.
+func DefaultFile() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".uor", "fuse.yaml"), nil
+}
+
+// ReadFile reads the configuration file at path.
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	file := &File{Path: path, Values: values, Profiles: map[string]Profile{}}
+	if raw, ok := values[profilesKey]; ok {
+		delete(values, profilesKey)
+		profiles, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: %s must be a map of profile names to flags", path, profilesKey)
+		}
+		for name, raw := range profiles {
+			profile, err := parseProfile(name, raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			file.Profiles[name] = profile
+		}
+	}
+	if raw, ok := values[mountsKey]; ok {
+		delete(values, mountsKey)
+		mounts, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: %s must be a list", path, mountsKey)
+		}
+		seen := map[string]bool{}
+		for i, raw := range mounts {
+			mount, err := parseMount(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s[%d]: %w", path, mountsKey, i, err)
+			}
+			if seen[mount.MountPoint()] {
+				return nil, fmt.Errorf("%s: %s[%d]: %s is mounted more than once", path, mountsKey, i, mount.MountPoint())
+			}
+			seen[mount.MountPoint()] = true
+			file.Mounts = append(file.Mounts, mount)
+		}
+	}
+	return file, nil
+}
+
+// parseMount parses a mount given by its mountpoint and reference, or
+// references as NAME=REFERENCE, or by args like a profile. Other keys
+// are flags.
+func parseMount(raw interface{}) (Mount, error) {
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return Mount{}, errors.New("mount must be a map of flags")
+	}
+	mount := Mount{Values: map[string]interface{}{}}
+	var mountPoint string
+	for key, value := range values {
+		switch key {
+		case "mountpoint":
+			mountPoint = fmt.Sprint(value)
+		case "reference":
+			mount.Args = append(mount.Args, fmt.Sprint(value))
+		case "references", argsKey:
+			list, ok := value.([]interface{})
+			if !ok {
+				return Mount{}, fmt.Errorf("%s must be a list", key)
+			}
+			for _, v := range list {
+				mount.Args = append(mount.Args, fmt.Sprint(v))
+			}
+		default:
+			mount.Values[key] = value
+		}
+	}
+	if mountPoint != "" {
+		mount.Args = append(mount.Args, mountPoint)
+	}
+	if len(mount.Args) == 0 {
+		return Mount{}, errors.New("mount needs a mountpoint")
+	}
+	return mount, nil
+}
+
+func parseProfile(name string, raw interface{}) (Profile, error) {
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %s must be a map of flags", name)
+	}
+	profile := Profile{Name: name, Values: map[string]interface{}{}}
+	for key, value := range values {
+		if key != argsKey {
+			profile.Values[key] = value
+			continue
+		}
+		args, ok := value.([]interface{})
+		if !ok {
+			return Profile{}, fmt.Errorf("profile %s: %s must be a list", name, argsKey)
+		}
+		for _, arg := range args {
+			profile.Args = append(profile.Args, fmt.Sprint(arg))
+		}
+	}
+	return profile, nil
+}
+
+// Profile returns the profile called name.
+func (f *File) Profile(name string) (Profile, error) {
+	profile, ok := f.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(f.Profiles))
+		for name := range f.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return Profile{}, fmt.Errorf("%s: no profile %q (profiles: %s)", f.Path, name, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// CommandValues returns the flag values in f for cmd: the top-level
+// values, overridden by the values in the section of each command from
+// the root down to cmd.
+func (f *File) CommandValues(cmd *cobra.Command) map[string]interface{} {
+	var path []string
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		path = append([]string{c.Name()}, path...)
+	}
+	return f.SectionValues(path...)
+}
+
+// SectionValues returns the flag values in f for the command with the
+// names path below the root, as CommandValues does.
+func (f *File) SectionValues(path ...string) map[string]interface{} {
+	merged := map[string]interface{}{}
+	section := f.Values
+	for i := 0; section != nil; i++ {
+		for key, value := range section {
+			if _, ok := value.(map[string]interface{}); !ok {
+				merged[key] = value
+			}
+		}
+		if i == len(path) {
+			break
+		}
+		section, _ = section[path[i]].(map[string]interface{})
+	}
+	return merged
+}
+
+// Check returns an error for a key of f that is neither a flag of root or
+// of one of its subcommands nor a section named after a subcommand, so
+// that misspelled keys are not silently ignored. Keys of a section must
+// be flags of that command or of its subcommands. Mount entries are
+// checked by the serve command.
+func (f *File) Check(root *cobra.Command) error {
+	if err := checkSection(root, f.Values); err != nil {
+		return fmt.Errorf("%s: %w", f.Path, err)
+	}
+	for _, profile := range f.Profiles {
+		for _, key := range sortedKeys(profile.Values) {
+			if !hasFlag(root, key) {
+				return fmt.Errorf("%s: profile %s: unknown flag %q", f.Path, profile.Name, key)
+			}
+		}
+	}
+	return nil
+}
+
+func checkSection(cmd *cobra.Command, values map[string]interface{}) error {
+	for _, key := range sortedKeys(values) {
+		section, ok := values[key].(map[string]interface{})
+		if !ok {
+			if !hasFlag(cmd, key) {
+				return fmt.Errorf("unknown flag %q", key)
+			}
+			continue
+		}
+		sub := subcommand(cmd, key)
+		if sub == nil {
+			return fmt.Errorf("unknown command %q", key)
+		}
+		if err := checkSection(sub, section); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// hasFlag reports whether cmd or one of its subcommands has the flag
+// name, including flags inherited from parents.
+func hasFlag(cmd *cobra.Command, name string) bool {
+	for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags(), cmd.InheritedFlags()} {
+		if flags.Lookup(name) != nil {
+			return true
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		if hasFlag(sub, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// subcommand returns the subcommand of cmd called name, or nil.
+func subcommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name || sub.HasAlias(name) {
+			return sub
+		}
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ApplyConfig sets flags of cmd that were not given on the command line
+// from, in increasing priority, the configuration file, the environment
+// and the selected profile. The configuration file is --config,
+// UOR_CONFIG or DefaultFile if it exists; the profile is --profile or
+// UOR_PROFILE. The File read, if any, is checked with Check and kept in
+// o.
+func (o *RootOptions) ApplyConfig(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	given := map[string]bool{}
+	flags.Visit(func(flag *pflag.Flag) {
+		given[flag.Name] = true
+	})
+	set := func(values map[string]interface{}, source string) error {
+		for name, value := range values {
+			if given[name] {
+				continue
+			}
+			if flags.Lookup(name) == nil {
+				continue
+			}
+			if err := SetFlag(flags, name, value); err != nil {
+				return fmt.Errorf("%s: %s: %w", source, name, err)
+			}
+		}
+		return nil
+	}
+
+	path, explicit := o.ConfigFile, o.ConfigFile != ""
+	if !explicit && o.UOR_CONFIG != "" {
+		path, explicit = o.UOR_CONFIG, true
+	}
+	if !explicit {
+		var err error
+		if path, err = DefaultFile(); err != nil {
+			return err
+		}
+	}
+	file, err := ReadFile(path)
+	switch {
+	case err == nil:
+		if err := file.Check(cmd.Root()); err != nil {
+			return err
+		}
+		o.File = file
+		if err := set(file.CommandValues(cmd), path); err != nil {
+			return err
+		}
+	case explicit || !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	if err := set(o.EnvConfig.FlagValues(), "environment"); err != nil {
+		return err
+	}
+
+	profile := o.Profile
+	if profile == "" {
+		profile = o.UOR_PROFILE
+	}
+	if profile == "" {
+		return nil
+	}
+	if o.File == nil {
+		return fmt.Errorf("profile %q selected but no configuration file found at %s", profile, path)
+	}
+	p, err := o.File.Profile(profile)
+	if err != nil {
+		return err
+	}
+	o.ProfileArgs = p.Args
+	return set(p.Values, "profile "+p.Name)
+}
+
+// SetFlag sets the flag name in flags to a value decoded from YAML.
+// Lists replace the values of slice flags.
+func SetFlag(flags *pflag.FlagSet, name string, value interface{}) error {
+	flag := flags.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("unknown flag --%s", name)
+	}
+	if value == nil {
+		return nil
+	}
+	if list, ok := value.([]interface{}); ok {
+		values := make([]string, 0, len(list))
+		for _, v := range list {
+			values = append(values, formatValue(v))
+		}
+		var err error
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			err = slice.Replace(values)
+		} else {
+			err = flag.Value.Set(strings.Join(values, ","))
+		}
+		if err != nil {
+			return err
+		}
+	} else if err := flag.Value.Set(formatValue(value)); err != nil {
+		return err
+	}
+	flag.Changed = true
+	return nil
+}
+
+func formatValue(value interface{}) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,246 @@
+package control
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/uor-framework/uor-fuse-go/cli/log"
+)
+
+// Commands accepted on the control socket.
+const (
+	CommandStatus    = "status"
+	CommandRefresh   = "refresh"
+	CommandFlush     = "flush"
+	CommandLogLevel  = "log-level"
+	CommandPrefetch  = "prefetch"
+	CommandSetFilter = "set-filter"
+)
+
+// Request is a single command sent to a mount's control socket.
+type Request struct {
+	Command string `json:"command"`
+	// Level is the log level for CommandLogLevel.
+	Level string `json:"level,omitempty"`
+	// Paths are the mount-relative paths for CommandPrefetch.
+	Paths []string `json:"paths,omitempty"`
+	// Query is the attribute query for CommandSetFilter. An empty query
+	// removes the filter.
+	Query string `json:"query,omitempty"`
+}
+
+// Response is the reply to a Request. Error is set if the command failed.
+type Response struct {
+	Error  string  `json:"error,omitempty"`
+	Status *Status `json:"status,omitempty"`
+}
+
+// Status describes a running mount.
+type Status struct {
+	PID         int          `json:"pid"`
+	MountPoint  string       `json:"mountPoint"`
+	Source      string       `json:"source,omitempty"`
+	Digest      string       `json:"digest,omitempty"`
+	Collections []Collection `json:"collections,omitempty"`
+	Filter      string       `json:"filter,omitempty"`
+	Nodes       int          `json:"nodes"`
+	Files       int          `json:"files"`
+	OpenFiles   int64        `json:"openFiles"`
+	CachedFiles int          `json:"cachedFiles"`
+	CacheBytes  int64        `json:"cacheBytes"`
+	CacheHits   int64        `json:"cacheHits"`
+	CacheMisses int64        `json:"cacheMisses"`
+	Fetching    int64        `json:"inFlightFetches"`
+	Prefetch    Prefetch     `json:"prefetch"`
+	LastRefresh time.Time    `json:"lastRefresh"`
+	LogLevel    string       `json:"logLevel"`
+}
+
+// Prefetch is the progress of background downloads into the blob cache.
+type Prefetch struct {
+	Running bool  `json:"running"`
+	Total   int64 `json:"total"`
+	Done    int64 `json:"done"`
+	Failed  int64 `json:"failed"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// CacheHitRatio returns the fraction of reads served from cache, or 0
+// before any read.
+func (s *Status) CacheHitRatio() float64 {
+	total := s.CacheHits + s.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(total)
+}
+
+// Collection is a collection mounted as a top-level directory.
+type Collection struct {
+	Name      string `json:"name"`
+	Reference string `json:"reference"`
+	Digest    string `json:"digest,omitempty"`
+}
+
+// Handler carries out control commands for a mount.
+type Handler interface {
+	Status() (*Status, error)
+	Refresh() error
+	Flush() error
+	SetLogLevel(level string) error
+	Prefetch(paths []string) error
+	SetFilter(query string) error
+}
+
+// SocketPath returns the default control socket for the mount at
+// mountPoint: ~/.uor/run/HASH.sock, where HASH is derived from the
+// absolute mount point.
+func SocketPath(mountPoint string) (string, error) {
+	abs, err := filepath.Abs(mountPoint)
+	if err != nil {
+		return "", err
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(home, ".uor", "run", hex.EncodeToString(sum[:8])+".sock"), nil
+}
+
+// Serve accepts requests on a Unix socket at path until ctx is done. A
+// stale socket left by a mount that exited is replaced, but a socket
+// still in use, or a file at path that is not a socket, is an error.
+func Serve(ctx context.Context, path string, handler Handler, logger log.Logger) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("control socket %s is in use by another mount", path)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("control socket %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go serveConn(conn, handler, logger)
+	}
+}
+
+// serveConn answers a single request on conn.
+func serveConn(conn net.Conn, handler Handler, logger log.Logger) {
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		logger.Warnf("invalid control request: %v", err)
+		_ = json.NewEncoder(conn).Encode(Response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	logger.Infof("Control command %s", req.Command)
+	resp := handle(req, handler)
+	if resp.Error != "" {
+		logger.Errorf("control command %s failed: %s", req.Command, resp.Error)
+	}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		logger.Warnf("error writing control response: %v", err)
+	}
+}
+
+func handle(req Request, handler Handler) Response {
+	var err error
+	switch req.Command {
+	case CommandStatus:
+		var status *Status
+		if status, err = handler.Status(); err == nil {
+			return Response{Status: status}
+		}
+	case CommandRefresh:
+		err = handler.Refresh()
+	case CommandFlush:
+		err = handler.Flush()
+	case CommandLogLevel:
+		err = handler.SetLogLevel(req.Level)
+	case CommandPrefetch:
+		if len(req.Paths) == 0 {
+			err = errors.New("no paths to prefetch")
+		} else {
+			err = handler.Prefetch(req.Paths)
+		}
+	case CommandSetFilter:
+		err = handler.SetFilter(req.Query)
+	default:
+		err = fmt.Errorf("unknown command %q", req.Command)
+	}
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	return Response{}
+}
+
+// Call sends req to the control socket at path and returns the response.
+// A command that failed in the mount is returned as an error.
+func Call(ctx context.Context, path string, req Request) (*Response, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to control socket: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("reading control response: %w", err)
+	}
+	if resp.Error != "" {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,132 @@
+package fs
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Stats summarises the state of a mount.
+type Stats struct {
+	// Digests maps each mounted collection name to the digest of its
+	// root manifest. The collection mounted with Source has an empty name.
+	Digests map[string]string
+	// Nodes is the number of files and directories, including views.
+	Nodes int
+	// Files is the number of collection files, excluding virtual views.
+	Files int
+	// OpenFiles is the number of files currently open.
+	OpenFiles int64
+	// CachedFiles is the number of files whose content is held in memory.
+	CachedFiles int
+	// CacheBytes is the size of the content held in memory.
+	CacheBytes int64
+	// CacheHits and CacheMisses count reads served from memory and
+	// reads that fetched content.
+	CacheHits   int64
+	CacheMisses int64
+	// Fetching is the number of blob fetches in flight.
+	Fetching int64
+	// Prefetch reports the progress of prefetching.
+	Prefetch PrefetchProgress
+	// LastRefresh is when the tree was last rebuilt.
+	LastRefresh time.Time
+}
+
+// virtualDirs are the views added to every collection root. They are
+// skipped when counting files so each file is counted once.
+var virtualDirs = map[string]bool{
+	byAttributeDir: true,
+	byDigestDir:    true,
+	metadataDir:    true,
+	queryViewDir:   true,
+}
+
+// Stats returns a summary of the mounted collections and the content cache.
+func (fs *UorFs) Stats() Stats {
+	defer fs.synchronize()()
+	stats := Stats{
+		Digests:     map[string]string{},
+		LastRefresh: fs.lastRefresh,
+		Nodes:       countNodes(fs.root, map[*UorFsNode]bool{}),
+		OpenFiles:   atomic.LoadInt64(&fs.openFiles),
+		CacheHits:   atomic.LoadInt64(&fs.cacheHits),
+		CacheMisses: atomic.LoadInt64(&fs.cacheMisses),
+		Fetching:    atomic.LoadInt64(&fs.fetching),
+		Prefetch:    fs.prefetch.snapshot(),
+	}
+	if fs.Source != "" {
+		stats.Digests[""] = collectionDigest(fs.root)
+	}
+	for name, c := range fs.collections {
+		stats.Digests[name] = collectionDigest(c.root)
+	}
+
+	cached := map[*DecayCache]bool{}
+	walkFiles(fs.root, func(_ string, node *UorFsNode) {
+		stats.Files++
+		if node.data != nil && node.data.data != nil && !cached[node.data] {
+			cached[node.data] = true
+			stats.CachedFiles++
+			stats.CacheBytes += int64(len(*node.data.data))
+		}
+	})
+	return stats
+}
+
+// FlushCache drops the cached content of every file and returns the
+// number of files flushed.
+func (fs *UorFs) FlushCache() int {
+	defer fs.synchronize()()
+	flushed := 0
+	walkFiles(fs.root, func(_ string, node *UorFsNode) {
+		if node.data != nil && node.data.data != nil {
+			node.data.Flush()
+			flushed++
+		}
+	})
+	fs.Logger.Infof("Flushed %d cached files", flushed)
+	return flushed
+}
+
+// collectionDigest returns the root manifest digest recorded in the .uor
+// directory of a collection.
+func collectionDigest(root *UorFsNode) string {
+	if node := childAt(root, metadataDir+"/digest"); node != nil {
+		return strings.TrimSpace(string(node.content))
+	}
+	return ""
+}
+
+// countNodes returns the number of distinct nodes below and including
+// node. Files listed in several views are counted once.
+func countNodes(node *UorFsNode, seen map[*UorFsNode]bool) int {
+	if seen[node] {
+		return 0
+	}
+	seen[node] = true
+	count := 1
+	for _, child := range node.children {
+		count += countNodes(child, seen)
+	}
+	return count
+}
+
+// walkFiles calls fn for each blob file below dir with its path relative
+// to dir, skipping virtual views and unresolved links.
+func walkFiles(dir *UorFsNode, fn func(path string, node *UorFsNode)) {
+	for name, child := range dir.children {
+		if virtualDirs[name] {
+			continue
+		}
+		if child.children != nil {
+			walkFiles(child, func(path string, node *UorFsNode) {
+				fn(name+"/"+path, node)
+			})
+			continue
+		}
+		if child.desc != nil {
+			fn(name, child)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// CollectionSpec names a collection reference mounted as a
+// top-level directory.
+type CollectionSpec struct {
+	Name      string
+	Reference string
+}
+
+// ParseCollectionSpec parses a collection in NAME=REFERENCE form.
+func ParseCollectionSpec(spec string) (CollectionSpec, error) {
+	name, reference, found := strings.Cut(spec, "=")
+	if !found {
+		return CollectionSpec{}, fmt.Errorf("collection %q: expected NAME=REFERENCE", spec)
+	}
+	c := CollectionSpec{Name: strings.TrimSpace(name), Reference: strings.TrimSpace(reference)}
+	return c, c.validate()
+}
+
+func (c CollectionSpec) validate() error {
+	switch {
+	case c.Name == "":
+		return errors.New("collection name must not be empty")
+	case c.Reference == "":
+		return fmt.Errorf("collection %q: reference must not be empty", c.Name)
+	case strings.Contains(c.Name, "/"):
+		return fmt.Errorf("collection %q: name must not contain '/'", c.Name)
+	case strings.HasPrefix(c.Name, "."):
+		// Dot names are reserved for virtual directories.
+		return fmt.Errorf("collection %q: name must not start with '.'", c.Name)
+	case len(c.Name) > 255:
+		return fmt.Errorf("collection %q: name too long", c.Name)
+	}
+	return nil
+}
+
+// mountedCollection is a collection reference loaded into the tree.
+type mountedCollection struct {
+	CollectionSpec
+	root *UorFsNode
+}
+
+// loadCollection builds the directory tree for a collection without
+// attaching it to the mount.
+func (fs *UorFs) loadCollection(spec CollectionSpec) (*mountedCollection, error) {
+	client, matcher := fs.filter()
+	root := newNode(0, 0, fuse.S_IFDIR|00555, fs.euid, fs.egid)
+	if err := fs.loadFromReference(fs.ctx, root, spec.Reference, client, matcher, 0, nil); err != nil {
+		return nil, err
+	}
+	return &mountedCollection{CollectionSpec: spec, root: root}, nil
+}
+
+// AddCollection loads a collection and mounts it at /NAME. An existing
+// collection with the same name is replaced.
+func (fs *UorFs) AddCollection(spec CollectionSpec) error {
+	defer fs.synchronizeReload()()
+	return fs.addCollection(spec)
+}
+
+func (fs *UorFs) addCollection(spec CollectionSpec) error {
+	if err := spec.validate(); err != nil {
+		return err
+	}
+	if fs.Source != "" {
+		return errors.New("cannot add collections to a single collection mount")
+	}
+
+	fs.Logger.Infof("Resolving artifacts for collection %s (%s)", spec.Name, spec.Reference)
+	c, err := fs.loadCollection(spec)
+	if err != nil {
+		return fmt.Errorf("collection %s: %w", spec.Name, err)
+	}
+
+	defer fs.synchronize()()
+	if _, exists := fs.root.children[spec.Name]; !exists {
+		fs.root.stat.Nlink++
+	}
+	fs.root.children[spec.Name] = c.root
+	fs.collections[spec.Name] = c
+	return nil
+}
+
+// RemoveCollection unmounts the collection at /NAME.
+func (fs *UorFs) RemoveCollection(name string) error {
+	defer fs.synchronizeReload()()
+	return fs.removeCollection(name)
+}
+
+func (fs *UorFs) removeCollection(name string) error {
+	defer fs.synchronize()()
+	if _, exists := fs.collections[name]; !exists {
+		return fmt.Errorf("collection %s is not mounted", name)
+	}
+	delete(fs.collections, name)
+	delete(fs.root.children, name)
+	fs.root.stat.Nlink--
+	fs.Logger.Infof("Removed collection %s", name)
+	return nil
+}
+
+// SetCollections reconciles the mounted collections with specs. Collections
+// that are new or whose reference changed are loaded, and collections no
+// longer listed are removed.
+func (fs *UorFs) SetCollections(specs []CollectionSpec) error {
+	defer fs.synchronizeReload()()
+	wanted := map[string]CollectionSpec{}
+	for _, spec := range specs {
+		if _, exists := wanted[spec.Name]; exists {
+			return fmt.Errorf("collection %s listed more than once", spec.Name)
+		}
+		wanted[spec.Name] = spec
+	}
+
+	var errs []string
+	for _, current := range fs.ListCollections() {
+		if _, exists := wanted[current.Name]; !exists {
+			if err := fs.removeCollection(current.Name); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	mounted := map[string]CollectionSpec{}
+	for _, current := range fs.ListCollections() {
+		mounted[current.Name] = current
+	}
+	for _, spec := range specs {
+		if current, exists := mounted[spec.Name]; exists && current.Reference == spec.Reference {
+			continue
+		}
+		if err := fs.addCollection(spec); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ListCollections lists the collections mounted as top-level directories.
+func (fs *UorFs) ListCollections() []CollectionSpec {
+	defer fs.synchronize()()
+	specs := make([]CollectionSpec, 0, len(fs.collections))
+	for _, c := range fs.collections {
+		specs = append(specs, c.CollectionSpec)
+	}
+	return specs
+}
@@ -0,0 +1,94 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Kinds of Change.
+const (
+	ChangeAdded      = "added"
+	ChangeRemoved    = "removed"
+	ChangeModified   = "modified"
+	ChangeAttributes = "attributes"
+)
+
+// Change is a difference in a file between two trees. Modified files
+// have new content; files with only Attributes changes keep it.
+type Change struct {
+	Path       string                     `json:"path"`
+	Kind       string                     `json:"change"`
+	OldDigest  string                     `json:"oldDigest,omitempty"`
+	NewDigest  string                     `json:"newDigest,omitempty"`
+	Attributes map[string]AttributeChange `json:"attributes,omitempty"`
+}
+
+// AttributeChange is the old and new value of an attribute. A value is
+// missing if the attribute was added or removed.
+type AttributeChange struct {
+	Old json.RawMessage `json:"old,omitempty"`
+	New json.RawMessage `json:"new,omitempty"`
+}
+
+// Diff compares the files in two listings from List by path and returns
+// the changes from old to new in path order. Directories are ignored.
+func Diff(old, new []FileInfo) []Change {
+	oldFiles, newFiles := filesByPath(old), filesByPath(new)
+	var changes []Change
+	for p, o := range oldFiles {
+		n, ok := newFiles[p]
+		if !ok {
+			changes = append(changes, Change{Path: p, Kind: ChangeRemoved, OldDigest: o.Digest})
+			continue
+		}
+		change := Change{Path: p, Attributes: diffAttributes(o.Attributes, n.Attributes)}
+		switch {
+		case o.Digest != n.Digest:
+			change.Kind, change.OldDigest, change.NewDigest = ChangeModified, o.Digest, n.Digest
+		case len(change.Attributes) != 0:
+			change.Kind = ChangeAttributes
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	for p, n := range newFiles {
+		if _, ok := oldFiles[p]; !ok {
+			changes = append(changes, Change{Path: p, Kind: ChangeAdded, NewDigest: n.Digest})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func filesByPath(infos []FileInfo) map[string]FileInfo {
+	files := map[string]FileInfo{}
+	for _, info := range infos {
+		if !info.Dir {
+			files[info.Path] = info
+		}
+	}
+	return files
+}
+
+// diffAttributes returns the attributes that differ between old and new.
+func diffAttributes(old, new map[string]json.RawMessage) map[string]AttributeChange {
+	changes := map[string]AttributeChange{}
+	for key, value := range old {
+		if newValue, ok := new[key]; !ok || !bytes.Equal(value, newValue) {
+			changes[key] = AttributeChange{Old: value, New: newValue}
+		}
+	}
+	for key, value := range new {
+		if _, ok := old[key]; !ok {
+			changes[key] = AttributeChange{New: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
@@ -0,0 +1,207 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/uor-framework/uor-client-go/registryclient"
+
+	"github.com/uor-framework/uor-fuse-go/cli/log"
+)
+
+// viewDirs are the views derived from the files of a collection. Unlike
+// the .uor metadata, they are not exported.
+var viewDirs = map[string]bool{
+	byAttributeDir: true,
+	byDigestDir:    true,
+	queryViewDir:   true,
+}
+
+// ExportOptions configure Export.
+type ExportOptions struct {
+	// Jobs is the number of files fetched and written in parallel.
+	Jobs int
+	// Xattrs applies the user.uor.* attributes of each file and directory
+	// as extended attributes where the target filesystem supports them.
+	Xattrs bool
+}
+
+// ExportResult counts what Export wrote.
+type ExportResult struct {
+	Dirs  int
+	Files int
+	Bytes int64
+}
+
+// exportEntry is a file or directory to export.
+type exportEntry struct {
+	path string
+	node *UorFsNode
+}
+
+// Export writes the mounted tree below dir as regular files and
+// directories, resolving linked collections and skipping views. Blob
+// content is read through the blob cache like reads of the mount, and
+// each file is written to a temporary file and renamed into place.
+func (fs *UorFs) Export(ctx context.Context, dir string, opts ExportOptions) (ExportResult, error) {
+	var result ExportResult
+	entries := fs.exportEntries()
+	client, _ := fs.filter()
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	// Directories first, so files can be written in any order.
+	var files []exportEntry
+	xattrs := &xattrWriter{enabled: opts.Xattrs, logger: fs.Logger}
+	for _, entry := range entries {
+		if entry.node.children == nil {
+			files = append(files, entry)
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(entry.path))
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return result, err
+		}
+		xattrs.apply(target, entry.node)
+		result.Dirs++
+	}
+	fs.Logger.Infof("Exporting %d files to %s with %d jobs", len(files), dir, jobs)
+
+	queue := make(chan exportEntry)
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var errs []string
+	var count, bytes int64
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range queue {
+				target := filepath.Join(dir, filepath.FromSlash(entry.path))
+				n, err := fs.exportFile(ctx, client, entry.node, target)
+				if err != nil {
+					fs.Logger.Errorf("error exporting %s: %v", entry.path, err)
+					errMu.Lock()
+					errs = append(errs, fmt.Sprintf("%s: %v", entry.path, err))
+					errMu.Unlock()
+					continue
+				}
+				xattrs.apply(target, entry.node)
+				fs.Logger.Debugf("exported %s (%d bytes)", entry.path, n)
+				atomic.AddInt64(&count, 1)
+				atomic.AddInt64(&bytes, n)
+			}
+		}()
+	}
+	for _, entry := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- entry
+	}
+	close(queue)
+	wg.Wait()
+
+	result.Files, result.Bytes = int(count), bytes
+	if len(errs) != 0 {
+		return result, fmt.Errorf("export failed for %d files: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return result, ctx.Err()
+}
+
+// exportEntries returns every directory and file to export, parents
+// before their children. Linked collections are loaded on the way.
+func (fs *UorFs) exportEntries() []exportEntry {
+	defer fs.synchronize()()
+	var entries []exportEntry
+	var walk func(dir *UorFsNode, prefix string)
+	walk = func(dir *UorFsNode, prefix string) {
+		for name, child := range dir.children {
+			if viewDirs[name] {
+				continue
+			}
+			p := path.Join(prefix, name)
+			if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+				fs.Logger.Warnf("not exporting %s: invalid file name", p)
+				continue
+			}
+			if child.children != nil {
+				fs.resolveLink(child)
+				entries = append(entries, exportEntry{path: p, node: child})
+				walk(child, p)
+				continue
+			}
+			if child.desc != nil || child.content != nil {
+				entries = append(entries, exportEntry{path: p, node: child})
+			}
+		}
+	}
+	walk(fs.root, "")
+	return entries
+}
+
+// exportFile writes the content of node to target and returns its size.
+func (fs *UorFs) exportFile(ctx context.Context, client registryclient.Remote, node *UorFsNode, target string) (int64, error) {
+	data := node.content
+	if node.desc != nil {
+		var err error
+		if data, err = fs.fetch(ctx, client, node); err != nil {
+			return 0, err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, err
+	}
+	return int64(len(data)), os.Rename(tmp.Name(), target)
+}
+
+// xattrWriter applies node attributes as extended attributes, giving up
+// after the first target that does not support them.
+type xattrWriter struct {
+	enabled bool
+	logger  log.Logger
+
+	once        sync.Once
+	unsupported int32
+}
+
+func (w *xattrWriter) apply(target string, node *UorFsNode) {
+	if !w.enabled || atomic.LoadInt32(&w.unsupported) != 0 {
+		return
+	}
+	for name, value := range node.xattrs {
+		err := setXattr(target, name, value)
+		if errors.Is(err, errXattrUnsupported) {
+			atomic.StoreInt32(&w.unsupported, 1)
+			w.once.Do(func() {
+				w.logger.Warnf("not setting extended attributes: %v", err)
+			})
+			return
+		}
+		if err != nil {
+			w.logger.Warnf("error setting %s on %s: %v", name, target, err)
+		}
+	}
+}
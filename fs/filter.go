@@ -0,0 +1,62 @@
+package fs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uor-framework/uor-client-go/registryclient"
+	"github.com/winfsp/cgofuse/fuse"
+
+	"github.com/uor-framework/uor-fuse-go/query"
+	"github.com/uor-framework/uor-fuse-go/tracing"
+)
+
+// Matcher returns the attribute filter applied to the mount.
+func (fs *UorFs) Matcher() query.Expression {
+	defer fs.synchronize()()
+	return fs.matcher
+}
+
+// SetFilter rebuilds every mounted collection with client and matcher and
+// swaps the new tree in at once. The client should be configured with
+// matcher as its pullable attributes. On error the current tree is kept.
+// Views created under .query are not carried over.
+func (fs *UorFs) SetFilter(client registryclient.Remote, matcher query.Expression) error {
+	defer fs.synchronizeReload()()
+	fs.Logger.Infof("Applying attribute query %q", query.Format(matcher))
+	client = fs.metrics.InstrumentRemote(tracing.InstrumentRemote(client))
+	root := newNode(0, 1, fuse.S_IFDIR|00555, fs.euid, fs.egid)
+	collections := map[string]*mountedCollection{}
+
+	if fs.Source != "" {
+		if err := fs.loadFromReference(fs.ctx, root, fs.Source, client, matcher, 0, nil); err != nil {
+			return err
+		}
+	}
+	for _, spec := range fs.ListCollections() {
+		c := &mountedCollection{
+			CollectionSpec: spec,
+			root:           newNode(0, 0, fuse.S_IFDIR|00555, fs.euid, fs.egid),
+		}
+		if err := fs.loadFromReference(fs.ctx, c.root, spec.Reference, client, matcher, 0, nil); err != nil {
+			return fmt.Errorf("collection %s: %w", spec.Name, err)
+		}
+		root.children[spec.Name] = c.root
+		root.stat.Nlink++
+		collections[spec.Name] = c
+	}
+
+	defer fs.synchronize()()
+	fs.root = root
+	fs.collections = collections
+	fs.client = client
+	fs.matcher = matcher
+	fs.lastRefresh = time.Now()
+	return nil
+}
+
+// filter returns the client and matcher currently used to load collections.
+func (fs *UorFs) filter() (registryclient.Remote, query.Expression) {
+	defer fs.synchronize()()
+	return fs.client, fs.matcher
+}
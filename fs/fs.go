@@ -3,6 +3,8 @@ package fs
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"sync"
@@ -12,15 +14,22 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	artifactspec "github.com/oras-project/artifacts-spec/specs-go/v1"
-	"github.com/uor-framework/uor-client-go/attributes/matchers"
 	"github.com/uor-framework/uor-client-go/model"
+	"github.com/uor-framework/uor-client-go/nodes/collection"
+	collectionloader "github.com/uor-framework/uor-client-go/nodes/collection/loader"
 	"github.com/uor-framework/uor-client-go/nodes/descriptor"
 	"github.com/uor-framework/uor-client-go/ocimanifest"
 	"github.com/uor-framework/uor-client-go/registryclient"
 	"github.com/winfsp/cgofuse/fuse"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/uor-framework/uor-fuse-go/cache"
 	"github.com/uor-framework/uor-fuse-go/cli/log"
 	"github.com/uor-framework/uor-fuse-go/config"
+	"github.com/uor-framework/uor-fuse-go/metrics"
+	"github.com/uor-framework/uor-fuse-go/query"
+	"github.com/uor-framework/uor-fuse-go/tracing"
 )
 
 type DecayCache struct {
@@ -28,17 +37,24 @@ type DecayCache struct {
 	decay    *time.Timer
 	duration *time.Duration
 	refCount *int32
-	logger   *log.Logger
+	// lock guards the cache; it is held by callers and taken by the decay
+	// timer before flushing.
+	lock    sync.Locker
+	logger  *log.Logger
+	metrics *metrics.Metrics
 }
 
-func NewDecayCache(data *[]byte, duration *time.Duration, logger *log.Logger) *DecayCache {
+func NewDecayCache(data *[]byte, duration *time.Duration, lock sync.Locker, logger *log.Logger, m *metrics.Metrics) *DecayCache {
 	cache := &DecayCache{
 		data:     data,
 		duration: duration,
+		lock:     lock,
 		logger:   logger,
 		refCount: new(int32),
+		metrics:  m,
 	}
 	*cache.refCount = 0
+	m.CacheAdd(len(*data))
 	return cache
 }
 
@@ -47,6 +63,9 @@ func (c *DecayCache) Flush() {
 		c.decay.Stop()
 		c.decay = nil
 	}
+	if c.data != nil {
+		c.metrics.CacheEvict(len(*c.data))
+	}
 	c.data = nil
 	(*c.logger).Debugf("Flushed cache for file")
 }
@@ -62,20 +81,58 @@ func (c *DecayCache) RemoveUser() {
 	atomic.AddInt32(c.refCount, -1)
 	//c.refCount -= 1
 	if *c.refCount == 0 {
-		c.decay = time.AfterFunc(*c.duration, c.Flush)
+		var decay *time.Timer
+		decay = time.AfterFunc(*c.duration, func() {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			// A user may have stopped the timer while this waited
+			// for the lock.
+			if c.decay == decay {
+				c.Flush()
+			}
+		})
+		c.decay = decay
 		(*c.logger).Debugf("Setting cache decay timer")
 	}
 }
 
 type UorFsOptions struct {
 	*config.RootOptions
-	Source         string
-	MountPoint     string
-	Insecure       bool
-	PlainHTTP      bool
-	Configs        []string
-	AttributeQuery string
-	NoVerify       bool
+	Source          string
+	MountPoint      string
+	Insecure        bool
+	PlainHTTP       bool
+	Configs         []string
+	AttributeQuery  string
+	NoVerify        bool
+	Where           string
+	LinkDepth       int
+	LinkNameAttr    string
+	Collections     []string
+	CollectionsFile string
+	MetricsAddr     string
+	LogFile         string
+	LogMaxSize      int
+	LogMaxBackups   int
+	LogSink         string
+	TraceEndpoint   string
+	TraceFile       string
+	ControlSocket   string
+	PrefetchAll     bool
+	PrefetchWhere   string
+	PrefetchGlobs   []string
+	PrefetchList    string
+	PrefetchJobs    int
+	RecordProfile   string
+	ReplayProfile   string
+	RefreshInterval time.Duration
+	FuseOptions     []string
+	// IgnoreLoadErrors keeps what could be loaded when a source or
+	// collection fails to load instead of failing.
+	IgnoreLoadErrors bool
+	// QueryViews adds the .query directories in which views are created
+	// with mkdir, which needs a writable mount.
+	QueryViews bool
 }
 
 type UorFs struct {
@@ -83,7 +140,8 @@ type UorFs struct {
 
 	*UorFsOptions
 	client  registryclient.Remote
-	matcher matchers.PartialAttributeMatcher
+	matcher query.Expression
+	metrics *metrics.Metrics
 
 	euid uint32
 	egid uint32
@@ -93,21 +151,46 @@ type UorFs struct {
 	root  *UorFsNode
 	ctx   context.Context
 
+	// reloadMutex serializes the changes to the mounted collections and
+	// filter, which load from the registry without holding mutex.
+	reloadMutex sync.Mutex
+
+	// collections holds the top-level collections when mounting
+	// more than one collection. It is empty when Source is set.
+	collections map[string]*mountedCollection
+
 	cacheDuration *time.Duration
+	lastRefresh   time.Time
+
+	// Counters reported by Stats.
+	openFiles   int64
+	cacheHits   int64
+	cacheMisses int64
+	fetching    int64
+
+	// blobs caches blob content on disk. It is nil without a CacheDir.
+	blobs    *cache.Cache
+	prefetch prefetchCounters
+	// profile records the files read, if RecordProfile is set.
+	profile *profileRecorder
 }
 
 type UorFsNode struct {
-	stat     fuse.Stat_t
-	xattrs   map[string][]byte
-	children map[string]*UorFsNode
-	data     *DecayCache
-	desc     *ocispec.Descriptor
+	stat      fuse.Stat_t
+	xattrs    map[string][]byte
+	children  map[string]*UorFsNode
+	data      *DecayCache
+	desc      *ocispec.Descriptor
+	content   []byte
+	reference string
+	link      *collectionLink
+	query     *queryDir
 }
 
 func newNode(dev uint64, ino uint64, mode uint32, uid uint32, gid uint32) *UorFsNode {
 	now := fuse.Now()
 	node := UorFsNode{
-		fuse.Stat_t{
+		stat: fuse.Stat_t{
 			Dev:      dev,
 			Ino:      ino,
 			Mode:     mode,
@@ -120,10 +203,6 @@ func newNode(dev uint64, ino uint64, mode uint32, uid uint32, gid uint32) *UorFs
 			Birthtim: now,
 			Flags:    0,
 		},
-		nil,
-		nil,
-		nil,
-		nil,
 	}
 	if fuse.S_IFDIR == node.stat.Mode&fuse.S_IFMT {
 		node.children = map[string]*UorFsNode{}
@@ -132,47 +211,83 @@ func newNode(dev uint64, ino uint64, mode uint32, uid uint32, gid uint32) *UorFs
 	return &node
 }
 
+// errNameTooLong is returned by findNode for a path with a name longer
+// than maxNameLen.
+var errNameTooLong = errors.New("file name too long")
+
+// maxNameLen is the longest name of a file in the mount.
+const maxNameLen = 255
+
+// lookupNode returns the node at path, or nil if there is none. It panics
+// with ENAMETOOLONG, which cgofuse returns to the caller, on a name longer
+// than maxNameLen, so it is only called from FUSE operations; other
+// callers use findNode.
 func (fs *UorFs) lookupNode(path string) *UorFsNode {
+	node, err := fs.findNode(path)
+	if err != nil {
+		panic(fuse.Error(-fuse.ENAMETOOLONG))
+	}
+	return node
+}
+
+// findNode returns the node at path, or nil if there is none. It returns
+// errNameTooLong on a name longer than maxNameLen.
+func (fs *UorFs) findNode(path string) (*UorFsNode, error) {
 	pathParts := strings.Split(path, "/")
 	parent := fs.root
 	node := parent
 	if path == "/" {
-		return fs.root
+		return fs.root, nil
 	}
 	for i, part := range pathParts {
 		if part == "" {
 			continue
 		}
-		if len(part) > 255 {
-			panic(fuse.Error(-fuse.ENAMETOOLONG))
+		if len(part) > maxNameLen {
+			return nil, errNameTooLong
 		}
+		fs.resolveLink(node)
 		node = node.children[part]
 		if node == nil {
-			return nil
+			return nil, nil
 		}
 		if i == len(pathParts)-1 {
-			return node
+			return node, nil
 		}
 	}
-	return nil
+	return nil, nil
 }
 
 func (fs *UorFs) Open(path string, flags int) (errc int, fh uint64) {
-	defer fs.synchronize()()
+	op := fs.startOp("Open", path)
+	defer op.end(&errc)
+	defer op.synchronize()()
+	if flags&fuse.O_ACCMODE != fuse.O_RDONLY {
+		return -fuse.EROFS, ^uint64(0)
+	}
 	if node := fs.lookupNode(path); node != nil {
+		op.setNode(node)
+		atomic.AddInt64(&fs.openFiles, 1)
 		return 0, 0
 	}
 	return -fuse.ENOENT, ^uint64(0)
 }
 
+func (fs *UorFs) Release(path string, fh uint64) int {
+	atomic.AddInt64(&fs.openFiles, -1)
+	return 0
+}
+
 func (fs *UorFs) Getattr(path string, stat *fuse.Stat_t, fh uint64) (errc int) {
-	defer fs.synchronize()()
-	fs.Logger.Debugf("Getattr path: %v", path)
+	op := fs.startOp("Getattr", path)
+	defer op.end(&errc)
+	defer op.synchronize()()
 
 	node := fs.lookupNode(path)
 	if node == nil {
 		return -fuse.ENOENT
 	}
+	op.setNode(node)
 
 	stat.Mode = node.stat.Mode
 	stat.Size = node.stat.Size
@@ -195,37 +310,63 @@ func (fs *UorFs) Getattr(path string, stat *fuse.Stat_t, fh uint64) (errc int) {
 }
 
 func (fs *UorFs) Read(path string, buff []byte, ofst int64, fh uint64) (n int) {
-	defer fs.synchronize()()
+	op := fs.startOp("Read", path)
+	defer op.end(&n)
+	defer op.synchronize()()
 
 	node := fs.lookupNode(path)
 	if node == nil {
 		return -fuse.ENOENT
 	}
+	op.setNode(node)
+	if node.content != nil {
+		n = copyAt(buff, node.content, ofst)
+		fs.metrics.AddBytesServed(n)
+		return n
+	}
+	if err := fs.profile.record(path); err != nil {
+		fs.Logger.Warnf("error recording prefetch profile: %v", err)
+	}
 
-	if node.data == nil {
-		nodeData, err := fs.client.GetContent(fs.ctx, fs.Source, *node.desc)
+	// Flushed caches keep their DecayCache with nil data.
+	if node.data == nil || node.data.data == nil {
+		fs.metrics.CacheMiss()
+		atomic.AddInt64(&fs.cacheMisses, 1)
+		nodeData, err := fs.fetch(op.ctx, fs.client, node)
 		if err != nil {
 			return -fuse.ENOENT
 		}
 
-		node.data = NewDecayCache(&nodeData, fs.cacheDuration, &fs.Logger)
+		node.data = NewDecayCache(&nodeData, fs.cacheDuration, &fs.mutex, &fs.Logger, fs.metrics)
+	} else {
+		fs.metrics.CacheHit()
+		atomic.AddInt64(&fs.cacheHits, 1)
 	}
 	node.data.AddUser()
 	defer node.data.RemoveUser()
 
+	n = copyAt(buff, *node.data.data, ofst)
+	fs.metrics.AddBytesServed(n)
+	return n
+}
+
+// copyAt copies data starting at ofst into buff and returns the number
+// of bytes copied.
+func copyAt(buff []byte, data []byte, ofst int64) int {
 	endofst := ofst + int64(len(buff))
-	if endofst > int64(len(*node.data.data)) {
-		endofst = int64(len(*node.data.data))
+	if endofst > int64(len(data)) {
+		endofst = int64(len(data))
 	}
 	if endofst < ofst {
 		return 0
 	}
-	n = copy(buff, (*node.data.data)[ofst:endofst])
-	return
+	return copy(buff, data[ofst:endofst])
 }
 
 func (fs *UorFs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) (errc int) {
-	defer fs.synchronize()()
+	op := fs.startOp("Readdir", path)
+	defer op.end(&errc)
+	defer op.synchronize()()
 	fill(".", nil, 0)
 	fill("..", nil, 0)
 
@@ -233,6 +374,7 @@ func (fs *UorFs) Readdir(path string, fill func(name string, stat *fuse.Stat_t,
 	if node == nil {
 		return -fuse.ENOENT
 	}
+	fs.resolveLink(node)
 
 	for name, child := range node.children {
 		if !fill(name, &child.stat, 0) {
@@ -244,11 +386,14 @@ func (fs *UorFs) Readdir(path string, fill func(name string, stat *fuse.Stat_t,
 }
 
 func (fs *UorFs) Listxattr(path string, fill func(name string) bool) (errc int) {
-	defer fs.synchronize()()
+	op := fs.startOp("Listxattr", path)
+	defer op.end(&errc)
+	defer op.synchronize()()
 	node := fs.lookupNode(path)
 	if node == nil {
 		return -fuse.ENOENT
 	}
+	op.setNode(node)
 	for name := range node.xattrs {
 		if !fill(name) {
 			return -fuse.ERANGE
@@ -258,11 +403,14 @@ func (fs *UorFs) Listxattr(path string, fill func(name string) bool) (errc int)
 }
 
 func (fs *UorFs) Getxattr(path string, name string) (errc int, xattr []byte) {
-	defer fs.synchronize()()
+	op := fs.startOp("Getxattr", path)
+	defer op.end(&errc)
+	defer op.synchronize()()
 	node := fs.lookupNode(path)
 	if node == nil {
 		return -fuse.ENOENT, nil
 	}
+	op.setNode(node)
 	if xattr, ok := node.xattrs[name]; !ok {
 		return -fuse.ENOATTR, nil
 	} else {
@@ -278,16 +426,47 @@ func (fs *UorFs) synchronize() func() {
 	}
 }
 
-// loadFromReference loads a collection from an image reference.
-func (fs *UorFs) loadFromReference(ctx context.Context, reference string, client registryclient.Remote) error {
+// synchronizeReload takes reloadMutex. It is taken before mutex.
+func (fs *UorFs) synchronizeReload() func() {
+	fs.reloadMutex.Lock()
+	return func() {
+		fs.reloadMutex.Unlock()
+	}
+}
 
-	layerDescriptors, err := getManifest(ctx, reference, client, fs.matcher)
+// errLinkCycle is returned by loadFromReference for a collection that is
+// one of its ancestors.
+var errLinkCycle = errors.New("cycle")
+
+// loadFromReference loads a collection from an image reference into the
+// directory node parent, keeping only files accepted by matcher. Linked
+// collections are added as unresolved subdirectories one level deeper
+// than depth. It returns errLinkCycle if the manifest digest of the
+// collection is in ancestors.
+func (fs *UorFs) loadFromReference(ctx context.Context, parent *UorFsNode, reference string, client registryclient.Remote, matcher query.Expression, depth int, ancestors []string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "LoadReference", trace.WithAttributes(
+		attribute.String("uor.reference", reference),
+		attribute.Int("uor.link_depth", depth),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	manifestDesc, manifestBytes, err := fetchManifest(ctx, client, reference)
+	if err != nil {
+		return err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor == manifestDesc.Digest.String() {
+			return errLinkCycle
+		}
+	}
+	layerDescriptors, err := getManifest(ctx, reference, client, matcher, manifestDesc, manifestBytes)
 	if err != nil {
 		return err
 	}
 
 	for _, layerInfo := range layerDescriptors {
 		layerInfo := layerInfo // fix &layerInfo
+		fs.indexDigest(parent, reference, layerInfo)
 
 		switch layerInfo.MediaType {
 		case ocimanifest.UORSchemaMediaType:
@@ -316,15 +495,7 @@ func (fs *UorFs) loadFromReference(ctx context.Context, reference string, client
 			return err
 		}
 
-		//uid, gid, _ := fuse.Getcontext()
-		node := newNode(0, 0, fuse.S_IFREG|00444, fs.euid, fs.egid) // 444
-		node.desc = &layerInfo
-		node.stat.Size = layerInfo.Size
-		node.xattrs = map[string][]byte{}
-		node.xattrs["user.uor.Digest"] = []byte(layerInfo.Digest.String())
-		if layerInfo.MediaType != "" {
-			node.xattrs["user.uor.MediaType"] = []byte(layerInfo.MediaType)
-		}
+		node := fs.newBlobNode(reference, layerInfo)
 		for _, attribute := range attributeSet.List() {
 			if attribute.Key() == ocispec.AnnotationTitle {
 				continue
@@ -332,16 +503,120 @@ func (fs *UorFs) loadFromReference(ctx context.Context, reference string, client
 			if jsonObj, err := json.Marshal(attribute.AsAny()); err != nil {
 				fs.Logger.Errorf("Unknown attribute value %v %v", attribute.Key(), err)
 			} else {
-				node.xattrs["user.uor.attributes."+attribute.Key()] = jsonObj
+				node.xattrs[attributeXattrPrefix+attribute.Key()] = jsonObj
 			}
 		}
-		fs.insertNode(filename, node)
+		fs.insertNode(parent, filename, node)
+		fs.indexAttributes(parent, filename, node, attributeSet)
 	}
 
-	return nil
+	fs.addMetadata(parent, reference, matcher, manifestDesc, manifestBytes, layerDescriptors)
+	if fs.QueryViews {
+		fs.addQueryDir(parent, reference, matcher)
+	}
+	return fs.addLinks(ctx, parent, client, matcher, manifestDesc, manifestBytes, depth, ancestors)
+}
+
+// fetch returns the content of the blob node from the blob cache, or
+// from the registry, adding it to the cache. A download holds the lock
+// of the blob in the cache, so concurrent mounts sharing the cache
+// download each blob once and the others wait and reuse it.
+func (fs *UorFs) fetch(ctx context.Context, client registryclient.Remote, node *UorFsNode) ([]byte, error) {
+	if fs.blobs == nil {
+		return fs.fetchRemote(ctx, client, node)
+	}
+	if data, ok := fs.cached(node); ok {
+		return data, nil
+	}
+
+	unlock, waited, err := fs.blobs.Lock(node.desc.Digest)
+	if err != nil {
+		fs.Logger.Warnf("downloading blob %s without lock: %v", node.desc.Digest, err)
+	} else {
+		defer unlock()
+	}
+	if waited {
+		fs.Logger.Debugf("waited for another download of blob %s", node.desc.Digest)
+	}
+	// Another download may have finished between the check above and
+	// taking the lock, whether or not we waited for it.
+	if data, ok := fs.cached(node); ok {
+		return data, nil
+	}
+
+	data, err := fs.fetchRemote(ctx, client, node)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.blobs.Put(*node.desc, data); err != nil {
+		fs.Logger.Warnf("error caching blob %s: %v", node.desc.Digest, err)
+	} else if err := fs.blobs.AddReference(node.desc.Digest, node.reference); err != nil {
+		fs.Logger.Warnf("error recording reference of cached blob %s: %v", node.desc.Digest, err)
+	}
+	return data, nil
+}
+
+// cached returns the content of the blob node from the blob cache. A
+// cached blob not matching its digest is deleted and reported missing,
+// so that it is fetched again.
+func (fs *UorFs) cached(node *UorFsNode) ([]byte, bool) {
+	data, err := fs.blobs.Get(*node.desc)
+	if err != nil {
+		if !cache.IsNotExist(err) || cache.IsCorrupt(err) {
+			fs.Logger.Warnf("error reading cached blob %s: %v", node.desc.Digest, err)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// fetchRemote returns the content of the blob node from the registry,
+// failing if it does not match the size and digest of the node.
+func (fs *UorFs) fetchRemote(ctx context.Context, client registryclient.Remote, node *UorFsNode) ([]byte, error) {
+	atomic.AddInt64(&fs.fetching, 1)
+	defer atomic.AddInt64(&fs.fetching, -1)
+	data, err := client.GetContent(ctx, node.reference, *node.desc)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.CheckContent(*node.desc, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// download adds the blob of node to the blob cache if it is missing and
+// returns the number of bytes fetched.
+func (fs *UorFs) download(ctx context.Context, client registryclient.Remote, node *UorFsNode) (int64, error) {
+	if fs.blobs.Has(*node.desc) {
+		return 0, nil
+	}
+	data, err := fs.fetch(ctx, client, node)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
 }
 
-func getManifest(ctx context.Context, reference string, client registryclient.Remote, matcher matchers.PartialAttributeMatcher) ([]ocispec.Descriptor, error) {
+// newBlobNode returns a read-only file node for the blob described by desc.
+func (fs *UorFs) newBlobNode(reference string, desc ocispec.Descriptor) *UorFsNode {
+	//uid, gid, _ := fuse.Getcontext()
+	node := newNode(0, 0, fuse.S_IFREG|00444, fs.euid, fs.egid) // 444
+	node.desc = &desc
+	node.reference = reference
+	node.stat.Size = desc.Size
+	node.xattrs = map[string][]byte{}
+	node.xattrs["user.uor.Digest"] = []byte(desc.Digest.String())
+	if desc.MediaType != "" {
+		node.xattrs["user.uor.MediaType"] = []byte(desc.MediaType)
+	}
+	return node
+}
+
+// getManifest returns the descriptors of the collection with the manifest
+// manifestBytes described by manifestDesc, keeping only the files
+// accepted by matcher. The manifest is not fetched again.
+func getManifest(ctx context.Context, reference string, client registryclient.Remote, matcher query.Expression, manifestDesc ocispec.Descriptor, manifestBytes []byte) ([]ocispec.Descriptor, error) {
 	//manifestDesc, manifestRc, err := client.GetManifest(ctx, reference)
 	//if err != nil {
 	//	return nil, err
@@ -354,10 +629,18 @@ func getManifest(ctx context.Context, reference string, client registryclient.Re
 	//manifest, err := bytesToManifest(ctx, manifestBytes, manifestDesc)
 	//fmt.Printf("%v\n", manifest)
 
-	graph, err := client.LoadCollection(ctx, reference)
-	if err != nil {
+	fetcher := func(ctx context.Context, desc ocispec.Descriptor) ([]byte, error) {
+		if desc.Digest == manifestDesc.Digest {
+			return manifestBytes, nil
+		}
+		return client.GetContent(ctx, reference, desc)
+	}
+	co := collection.New(reference)
+	if err := collectionloader.LoadFromManifest(ctx, co, fetcher, manifestDesc); err != nil {
 		return nil, err
 	}
+	co.Location = reference
+	graph := *co
 
 	// Filter the collection per the matcher criteria
 	if matcher != nil {
@@ -468,19 +751,32 @@ func artifactToOCI(desc artifactspec.Descriptor) ocispec.Descriptor {
 	}
 }
 
-// TODO run periodically to detect changes?
-func (fs *UorFs) buildFsNodes(ctx context.Context) {
-	client := fs.client
-	err := fs.loadFromReference(ctx, fs.Source, client)
-	if err != nil {
-		fs.Logger.Infof("%v", err)
-		//return err
+// buildFsNodes loads the source or collections of fs into its tree. A
+// collection that fails to load is left out and its error returned once
+// the others are loaded.
+func (fs *UorFs) buildFsNodes(ctx context.Context) error {
+	if fs.Source != "" {
+		return fs.loadFromReference(ctx, fs.root, fs.Source, fs.client, fs.matcher, 0, nil)
+	}
+
+	var errs []string
+	for _, s := range fs.UorFsOptions.Collections {
+		spec, err := ParseCollectionSpec(s)
+		if err == nil {
+			err = fs.AddCollection(spec)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "; "))
 	}
+	return nil
 }
 
-func (fs *UorFs) insertNode(path string, node *UorFsNode) {
+func (fs *UorFs) insertNode(parent *UorFsNode, path string, node *UorFsNode) {
 	pathParts := strings.Split(path, "/")
-	parent := fs.root
 	for i, part := range pathParts {
 		if parent.children == nil {
 			parent.children = map[string]*UorFsNode{}
@@ -497,21 +793,60 @@ func (fs *UorFs) insertNode(path string, node *UorFsNode) {
 	}
 }
 
-func NewUorFs(ctx context.Context, o UorFsOptions, client registryclient.Client, matcher matchers.PartialAttributeMatcher) *UorFs {
+// NewUorFs builds the filesystem tree for o. Metrics are recorded in m,
+// which may be nil. Errors loading the source or collections are returned
+// unless o.IgnoreLoadErrors is set, in which case they are logged and the
+// tree holds what could be loaded.
+func NewUorFs(ctx context.Context, o UorFsOptions, client registryclient.Client, matcher query.Expression, m *metrics.Metrics) (*UorFs, error) {
 	duration := 5 * time.Minute
 	fs := UorFs{
 		UorFsOptions:  &o,
-		client:        client,
+		client:        m.InstrumentRemote(tracing.InstrumentRemote(client)),
 		matcher:       matcher,
+		metrics:       m,
 		ctx:           ctx,
 		cacheDuration: &duration,
+		collections:   map[string]*mountedCollection{},
 	}
-	defer fs.synchronize()()
 	//fs.ino++
 	//uid, gid, _ := fuse.Getcontext()
 	fs.euid, fs.egid = uint32(os.Geteuid()), uint32(os.Getegid())
+	if o.RootOptions != nil && o.CacheDir != "" {
+		blobs, err := cache.New(o.CacheDir)
+		if err != nil {
+			fs.Logger.Warnf("blob cache disabled: %v", err)
+		} else {
+			fs.blobs = blobs
+			if o.CacheMaxSize != "" {
+				maxSize, err := cache.ParseSize(o.CacheMaxSize)
+				if err != nil {
+					return nil, fmt.Errorf("invalid cache size: %w", err)
+				}
+				blobs.SetMaxSize(maxSize)
+			}
+		}
+	}
+	if o.RecordProfile != "" {
+		mounted := o.Source
+		if mounted == "" {
+			mounted = strings.Join(o.Collections, " ")
+		}
+		profile, err := newProfileRecorder(o.RecordProfile, mounted)
+		if err != nil {
+			fs.Logger.Warnf("not recording prefetch profile: %v", err)
+		} else {
+			fs.profile = profile
+		}
+	}
 	fs.root = newNode(0, 1, fuse.S_IFDIR|00555, fs.euid, fs.egid)
 
-	fs.buildFsNodes(ctx)
-	return &fs
+	if err := fs.buildFsNodes(ctx); err != nil {
+		if !o.IgnoreLoadErrors {
+			fs.Destroy()
+			return nil, err
+		}
+		fs.Logger.Errorf("%v", err)
+	}
+	fs.lastRefresh = time.Now()
+	return &fs, nil
 }
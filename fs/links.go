@@ -0,0 +1,159 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/uor-framework/uor-client-go/ocimanifest"
+	"github.com/uor-framework/uor-client-go/registryclient"
+	"github.com/winfsp/cgofuse/fuse"
+
+	"github.com/uor-framework/uor-fuse-go/query"
+)
+
+// collectionLink describes a linked collection that has not been
+// loaded into the tree yet.
+type collectionLink struct {
+	reference string
+	matcher   query.Expression
+	depth     int
+	// ancestors holds the manifest digests of every collection
+	// between the mount root and the link, used to detect cycles.
+	ancestors []string
+}
+
+// addLinks adds a directory under parent for every collection linked from
+// the manifest described by manifestDesc. The directories are resolved
+// lazily by resolveLink.
+func (fs *UorFs) addLinks(ctx context.Context, parent *UorFsNode, client registryclient.Remote, matcher query.Expression, manifestDesc ocispec.Descriptor, manifestBytes []byte, depth int, ancestors []string) error {
+	if depth >= fs.LinkDepth {
+		return nil
+	}
+
+	links, err := ocimanifest.ResolveCollectionLinks(bytes.NewReader(manifestBytes))
+	if err != nil {
+		if errors.Is(err, ocimanifest.ErrNoCollectionLinks) {
+			fs.Logger.Debugf("collection %s has no links", manifestDesc.Digest)
+			return nil
+		}
+		return err
+	}
+
+	// Copy so sibling links do not share a backing array.
+	chain := append(append([]string{}, ancestors...), manifestDesc.Digest.String())
+	for _, link := range links {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			continue
+		}
+		name := fs.linkName(ctx, link, client)
+		if len(name) > maxNameLen {
+			fs.Logger.Warnf("linked collection %s has a name longer than %d bytes, ignoring", link, maxNameLen)
+			continue
+		}
+		if parent.children[name] != nil {
+			fs.Logger.Warnf("linked collection %s conflicts with existing path %s, ignoring", link, name)
+			continue
+		}
+
+		fs.Logger.Debugf("found link %s for collection %s", link, manifestDesc.Digest)
+		node := newNode(0, 0, fuse.S_IFDIR|00555, fs.euid, fs.egid)
+		node.reference = link
+		node.link = &collectionLink{
+			reference: link,
+			matcher:   matcher,
+			depth:     depth + 1,
+			ancestors: chain,
+		}
+		node.xattrs = map[string][]byte{
+			"user.uor.link": []byte(link),
+		}
+		fs.insertNode(parent, name, node)
+	}
+	return nil
+}
+
+// resolveLink loads the linked collection for node on first use. Errors are
+// logged and leave the directory empty so a broken link does not hide the
+// rest of the tree.
+func (fs *UorFs) resolveLink(node *UorFsNode) {
+	if node.link == nil {
+		return
+	}
+	link := node.link
+	node.link = nil
+
+	fs.Logger.Infof("Resolving linked collection %s", link.reference)
+	err := fs.loadFromReference(fs.ctx, node, link.reference, fs.client, link.matcher, link.depth, link.ancestors)
+	switch {
+	case errors.Is(err, errLinkCycle):
+		fs.Logger.Warnf("linked collection %s forms a cycle, not descending", link.reference)
+		node.xattrs["user.uor.link.error"] = []byte(err.Error())
+	case err != nil:
+		fs.Logger.Errorf("error loading linked collection %s: %v", link.reference, err)
+		node.xattrs["user.uor.link.error"] = []byte(err.Error())
+	}
+}
+
+// linkName returns the directory name for a linked collection, escaped
+// like the names of views to a single path component. When LinkNameAttr
+// is set and the linked manifest carries that attribute, its value is
+// used, otherwise the reference itself is used.
+func (fs *UorFs) linkName(ctx context.Context, reference string, client registryclient.Remote) string {
+	return escapeName(fs.rawLinkName(ctx, reference, client))
+}
+
+// rawLinkName returns the unescaped directory name for a linked collection.
+func (fs *UorFs) rawLinkName(ctx context.Context, reference string, client registryclient.Remote) string {
+	if fs.LinkNameAttr == "" {
+		return reference
+	}
+
+	_, manifestRc, err := client.GetManifest(ctx, reference)
+	if err != nil {
+		fs.Logger.Debugf("unable to fetch manifest for link %s: %v", reference, err)
+		return reference
+	}
+	defer manifestRc.Close()
+
+	var manifest ocispec.Manifest
+	if err := json.NewDecoder(manifestRc).Decode(&manifest); err != nil {
+		fs.Logger.Debugf("unable to decode manifest for link %s: %v", reference, err)
+		return reference
+	}
+	attributeSet, err := ocimanifest.AnnotationsToAttributeSet(manifest.Annotations, nil)
+	if err != nil {
+		fs.Logger.Debugf("unable to parse attributes for link %s: %v", reference, err)
+		return reference
+	}
+	attribute := attributeSet.Find(fs.LinkNameAttr)
+	if attribute == nil {
+		return reference
+	}
+	if name, err := attribute.AsString(); err == nil && name != "" {
+		return name
+	}
+	if name, err := json.Marshal(attribute.AsAny()); err == nil {
+		return string(name)
+	}
+	return reference
+}
+
+// childAt returns the node at path relative to parent without resolving links.
+func childAt(parent *UorFsNode, path string) *UorFsNode {
+	node := parent
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		node = node.children[part]
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
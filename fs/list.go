@@ -0,0 +1,120 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// attributeXattrPrefix prefixes the extended attributes holding the
+// collection attributes of a file as JSON.
+const attributeXattrPrefix = "user.uor.attributes."
+
+// FileInfo describes a file or directory of the tree as it would appear
+// in the mount.
+type FileInfo struct {
+	Path       string                     `json:"path"`
+	Dir        bool                       `json:"dir,omitempty"`
+	Size       int64                      `json:"size"`
+	Digest     string                     `json:"digest,omitempty"`
+	MediaType  string                     `json:"mediaType,omitempty"`
+	Link       string                     `json:"link,omitempty"`
+	Attributes map[string]json.RawMessage `json:"attributes,omitempty"`
+}
+
+// List describes the file at p, or the entries of the directory at p in
+// name order, loading linked collections on the way. Recursive lists
+// every entry below the directory. Names starting with '.', like the
+// metadata and views of collections, are skipped unless all is set.
+func (fs *UorFs) List(p string, recursive bool, all bool) ([]FileInfo, error) {
+	defer fs.synchronize()()
+	p = strings.Trim(p, "/")
+	node, err := fs.findNode("/" + p)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", p, err)
+	case node == nil:
+		return nil, fmt.Errorf("%s: %w", p, os.ErrNotExist)
+	}
+	if node.children == nil {
+		return []FileInfo{fileInfo(p, node)}, nil
+	}
+
+	var infos []FileInfo
+	var list func(dir *UorFsNode, prefix string)
+	list = func(dir *UorFsNode, prefix string) {
+		fs.resolveLink(dir)
+		names := make([]string, 0, len(dir.children))
+		for name := range dir.children {
+			if all || !strings.HasPrefix(name, ".") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := dir.children[name]
+			childPath := path.Join(prefix, name)
+			infos = append(infos, fileInfo(childPath, child))
+			if recursive && child.children != nil {
+				list(child, childPath)
+			}
+		}
+	}
+	list(node, p)
+	return infos, nil
+}
+
+// fileInfo describes node at path p.
+func fileInfo(p string, node *UorFsNode) FileInfo {
+	info := FileInfo{
+		Path: p,
+		Dir:  node.children != nil,
+		Size: node.stat.Size,
+		Link: string(node.xattrs["user.uor.link"]),
+	}
+	if node.desc != nil {
+		info.Digest = node.desc.Digest.String()
+		info.MediaType = node.desc.MediaType
+	}
+	for name, value := range node.xattrs {
+		if key := strings.TrimPrefix(name, attributeXattrPrefix); key != name {
+			if info.Attributes == nil {
+				info.Attributes = map[string]json.RawMessage{}
+			}
+			info.Attributes[key] = json.RawMessage(value)
+		}
+	}
+	return info
+}
+
+// ReadFile returns the content of the file at p, read through the blob
+// cache like reads of the mount. Blob content is verified against its
+// digest by fetch, which deletes and downloads again a cached blob that
+// does not match.
+func (fs *UorFs) ReadFile(ctx context.Context, p string) ([]byte, error) {
+	unlock := fs.synchronize()
+	p = strings.Trim(p, "/")
+	node, err := fs.findNode("/" + p)
+	unlock()
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", p, err)
+	case node == nil:
+		return nil, fmt.Errorf("%s: %w", p, os.ErrNotExist)
+	case node.children != nil:
+		return nil, fmt.Errorf("%s: is a directory", p)
+	case node.desc == nil:
+		return node.content, nil
+	}
+
+	client, _ := fs.filter()
+	data, err := fs.fetch(ctx, client, node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return data, nil
+}
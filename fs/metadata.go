@@ -0,0 +1,74 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/uor-framework/uor-client-go/ocimanifest"
+	"github.com/uor-framework/uor-client-go/registryclient"
+	"github.com/winfsp/cgofuse/fuse"
+	orascontent "oras.land/oras-go/v2/content"
+
+	"github.com/uor-framework/uor-fuse-go/query"
+)
+
+// metadataDir is the virtual directory exposing collection provenance.
+const metadataDir = ".uor"
+
+// fetchManifest returns the root manifest descriptor and content for reference.
+func fetchManifest(ctx context.Context, client registryclient.Remote, reference string) (ocispec.Descriptor, []byte, error) {
+	desc, manifestRc, err := client.GetManifest(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	defer manifestRc.Close()
+	manifestBytes, err := orascontent.ReadAll(manifestRc, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	return desc, manifestBytes, nil
+}
+
+// addMetadata populates the .uor directory of the collection rooted at parent.
+func (fs *UorFs) addMetadata(parent *UorFsNode, reference string, matcher query.Expression, manifestDesc ocispec.Descriptor, manifestBytes []byte, descs []ocispec.Descriptor) {
+	add := func(name string, node *UorFsNode) {
+		fs.insertNode(parent, metadataDir+"/"+name, node)
+	}
+
+	add("manifest.json", fs.newContentNode(manifestBytes))
+	add("reference", fs.newContentNode([]byte(reference+"\n")))
+	add("digest", fs.newContentNode([]byte(manifestDesc.Digest.String()+"\n")))
+	add("query.json", fs.newContentNode(queryJSON(matcher)))
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		fs.Logger.Debugf("unable to decode manifest for %s: %v", reference, err)
+	} else if manifest.Config.Digest != "" {
+		add("config.json", fs.newBlobNode(reference, manifest.Config))
+	}
+
+	for _, desc := range descs {
+		if desc.MediaType == ocimanifest.UORSchemaMediaType {
+			add("schema.json", fs.newBlobNode(reference, desc))
+			break
+		}
+	}
+}
+
+// newContentNode returns a read-only file node serving content from memory.
+func (fs *UorFs) newContentNode(content []byte) *UorFsNode {
+	node := newNode(0, 0, fuse.S_IFREG|00444, fs.euid, fs.egid)
+	node.content = content
+	node.stat.Size = int64(len(content))
+	return node
+}
+
+// queryJSON returns an attribute query as JSON.
+func queryJSON(expr query.Expression) []byte {
+	content, err := json.MarshalIndent(map[string]string{"where": query.Format(expr)}, "", "  ")
+	if err != nil {
+		return []byte("{}\n")
+	}
+	return append(content, '\n')
+}
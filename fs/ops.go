@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/uor-framework/uor-fuse-go/cli/log"
+	"github.com/uor-framework/uor-fuse-go/tracing"
+)
+
+// fuseOp tracks a FUSE callback so it can be recorded in metrics, traced
+// and logged with structured fields when it returns.
+type fuseOp struct {
+	fs     *UorFs
+	name   string
+	path   string
+	digest string
+	start  time.Time
+	ctx    context.Context
+	span   trace.Span
+}
+
+// startOp begins tracking the FUSE callback name for path.
+func (fs *UorFs) startOp(name string, path string) *fuseOp {
+	ctx, span := tracing.Tracer().Start(fs.ctx, "fuse."+name,
+		trace.WithAttributes(attribute.String("fuse.path", path)))
+	return &fuseOp{fs: fs, name: name, path: path, start: time.Now(), ctx: ctx, span: span}
+}
+
+// synchronize acquires the filesystem lock like UorFs.synchronize and
+// records the time spent waiting for it on the span.
+func (op *fuseOp) synchronize() func() {
+	start := time.Now()
+	unlock := op.fs.synchronize()
+	op.span.AddEvent("lock acquired", trace.WithAttributes(
+		attribute.Float64("lock.wait_ms", float64(time.Since(start))/float64(time.Millisecond))))
+	return unlock
+}
+
+// setNode records the digest of the blob node resolves to, if any.
+func (op *fuseOp) setNode(node *UorFsNode) {
+	if node != nil && node.desc != nil {
+		op.digest = node.desc.Digest.String()
+		op.span.SetAttributes(attribute.String("uor.digest", op.digest))
+	}
+}
+
+// end records the callback as returning *result, a negative errno on
+// failure. It takes a pointer so it can be deferred with a named result.
+func (op *fuseOp) end(result *int) {
+	latency := time.Since(op.start)
+	op.fs.metrics.ObserveOp(op.name, latency, *result)
+
+	errno := 0
+	if *result < 0 {
+		errno = -*result
+		op.span.SetStatus(codes.Error, "")
+	}
+	op.span.SetAttributes(attribute.Int("fuse.errno", errno))
+	op.span.End()
+
+	fields := log.Fields{
+		"op":         op.name,
+		"path":       op.path,
+		"latency_ms": float64(latency) / float64(time.Millisecond),
+		"errno":      errno,
+	}
+	if op.digest != "" {
+		fields["digest"] = op.digest
+	}
+	op.fs.Logger.WithFields(fields).Debugf("FUSE %s", op.name)
+}
@@ -0,0 +1,216 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uor-framework/uor-client-go/nodes/descriptor"
+
+	"github.com/uor-framework/uor-fuse-go/query"
+)
+
+// PrefetchOptions select the files PrefetchFiles downloads into the
+// blob cache. A file is selected if any option selects it.
+type PrefetchOptions struct {
+	// All selects every file.
+	All bool
+	// Where selects files whose attributes match the expression.
+	Where query.Expression
+	// Globs select files whose path in the mount matches one of the
+	// patterns, in path.Match syntax.
+	Globs []string
+	// Paths select files, and every file below directories, by their
+	// path in the mount. They are fetched first, in order.
+	Paths []string
+	// Jobs is the number of blobs fetched in parallel.
+	Jobs int
+}
+
+// PrefetchProgress counts the files selected by PrefetchFiles calls.
+type PrefetchProgress struct {
+	Running int64 `json:"running"`
+	Total   int64 `json:"total"`
+	Done    int64 `json:"done"`
+	Failed  int64 `json:"failed"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// prefetchCounters is the live form of PrefetchProgress.
+type prefetchCounters struct {
+	running, total, done, failed, bytes int64
+}
+
+func (c *prefetchCounters) snapshot() PrefetchProgress {
+	return PrefetchProgress{
+		Running: atomic.LoadInt64(&c.running),
+		Total:   atomic.LoadInt64(&c.total),
+		Done:    atomic.LoadInt64(&c.done),
+		Failed:  atomic.LoadInt64(&c.failed),
+		Bytes:   atomic.LoadInt64(&c.bytes),
+	}
+}
+
+// prefetchFile is a file selected for prefetching.
+type prefetchFile struct {
+	path string
+	node *UorFsNode
+}
+
+// PrefetchFiles downloads the files selected by opts into the blob cache
+// with up to opts.Jobs parallel fetches, logging progress as it goes.
+// Files already cached are skipped. Reads of prefetched files are served
+// from the cache instead of the registry.
+func (fs *UorFs) PrefetchFiles(ctx context.Context, opts PrefetchOptions) error {
+	if fs.blobs == nil {
+		return errors.New("prefetching requires a cache directory")
+	}
+	files, err := fs.selectPrefetch(opts)
+	if err != nil {
+		return err
+	}
+	client, _ := fs.filter()
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	total := int64(len(files))
+	var done, failed, bytes int64
+	atomic.AddInt64(&fs.prefetch.running, 1)
+	defer atomic.AddInt64(&fs.prefetch.running, -1)
+	atomic.AddInt64(&fs.prefetch.total, total)
+	fs.Logger.Infof("Prefetching %d files with %d jobs", total, jobs)
+
+	stopProgress := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fs.Logger.Infof("Prefetched %d/%d files (%d bytes, %d failed)",
+					atomic.LoadInt64(&done), total, atomic.LoadInt64(&bytes), atomic.LoadInt64(&failed))
+			case <-stopProgress:
+				return
+			}
+		}
+	}()
+
+	queue := make(chan prefetchFile)
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var errs []string
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range queue {
+				n, err := fs.download(ctx, client, file.node)
+				if err != nil {
+					fs.Logger.Warnf("error prefetching %s: %v", file.path, err)
+					atomic.AddInt64(&failed, 1)
+					atomic.AddInt64(&fs.prefetch.failed, 1)
+					errMu.Lock()
+					errs = append(errs, fmt.Sprintf("%s: %v", file.path, err))
+					errMu.Unlock()
+					continue
+				}
+				atomic.AddInt64(&done, 1)
+				atomic.AddInt64(&bytes, n)
+				atomic.AddInt64(&fs.prefetch.done, 1)
+				atomic.AddInt64(&fs.prefetch.bytes, n)
+			}
+		}()
+	}
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- file
+	}
+	close(queue)
+	wg.Wait()
+	close(stopProgress)
+
+	fs.Logger.Infof("Prefetched %d/%d files (%d bytes, %d failed)", done, total, bytes, failed)
+	if len(errs) != 0 {
+		return fmt.Errorf("prefetch failed for %d files: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return ctx.Err()
+}
+
+// selectPrefetch returns the files selected by opts, each once, with
+// files from opts.Paths first. Paths that do not exist are skipped.
+func (fs *UorFs) selectPrefetch(opts PrefetchOptions) ([]prefetchFile, error) {
+	for _, pattern := range opts.Globs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+
+	defer fs.synchronize()()
+	var files []prefetchFile
+	seen := map[*UorFsNode]bool{}
+	add := func(p string, node *UorFsNode) {
+		if !seen[node] {
+			seen[node] = true
+			files = append(files, prefetchFile{path: p, node: node})
+		}
+	}
+
+	for _, p := range opts.Paths {
+		p = strings.Trim(p, "/")
+		node, err := fs.findNode("/" + p)
+		switch {
+		case err != nil:
+			fs.Logger.Warnf("not prefetching %s: %v", p, err)
+		case node == nil:
+			fs.Logger.Warnf("not prefetching %s: no such file or directory", p)
+		case node.children != nil:
+			fs.resolveLink(node)
+			walkFiles(node, func(name string, child *UorFsNode) {
+				add(path.Join(p, name), child)
+			})
+		case node.desc != nil:
+			add(p, node)
+		}
+	}
+
+	if opts.All || opts.Where != nil || len(opts.Globs) != 0 {
+		walkFiles(fs.root, func(p string, node *UorFsNode) {
+			if opts.All || matchesGlob(opts.Globs, p) || fs.matchesWhere(opts.Where, node) {
+				add(p, node)
+			}
+		})
+	}
+	return files, nil
+}
+
+func matchesGlob(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if match, _ := path.Match(pattern, p); match {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWhere reports whether the attributes of node match expr.
+func (fs *UorFs) matchesWhere(expr query.Expression, node *UorFsNode) bool {
+	if expr == nil {
+		return false
+	}
+	n, err := descriptor.NewNode(node.desc.Digest.String(), *node.desc)
+	if err != nil {
+		fs.Logger.Debugf("skipping %s: %v", node.desc.Digest, err)
+		return false
+	}
+	match, err := expr.Matches(n)
+	return err == nil && match
+}
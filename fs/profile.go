@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// profileRecorder writes the path of each file the first time it is
+// read, building a prefetch profile that a later mount can replay with
+// PrefetchOptions.Paths.
+type profileRecorder struct {
+	mu   sync.Mutex
+	file *os.File
+	seen map[string]bool
+}
+
+// newProfileRecorder truncates the profile at path and writes a header
+// naming what is mounted.
+func newProfileRecorder(path string, mounted string) (*profileRecorder, error) {
+	file, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintf(file, "# prefetch profile for %s recorded %s\n", mounted, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &profileRecorder{file: file, seen: map[string]bool{}}, nil
+}
+
+// record appends path to the profile unless it was read before. Lines
+// are written as they happen so the profile survives a crashed mount.
+func (r *profileRecorder) record(path string) error {
+	if r == nil {
+		return nil
+	}
+	path = strings.TrimPrefix(path, "/")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen[path] {
+		return nil
+	}
+	r.seen[path] = true
+	_, err := fmt.Fprintln(r.file, path)
+	return err
+}
+
+func (r *profileRecorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// Destroy is called when the file system is unmounted and closes the
+// prefetch profile being recorded.
+func (fs *UorFs) Destroy() {
+	if err := fs.profile.Close(); err != nil {
+		fs.Logger.Warnf("error closing prefetch profile: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package fs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/uor-framework/uor-client-go/attributes"
+	"github.com/uor-framework/uor-client-go/attributes/matchers"
+	"github.com/uor-framework/uor-client-go/registryclient"
+	"github.com/winfsp/cgofuse/fuse"
+
+	"github.com/uor-framework/uor-fuse-go/query"
+)
+
+// queryViewDir is the directory in which views are created
+// with mkdir.
+const queryViewDir = ".query"
+
+// queryDir holds what is needed to build a filtered view of a
+// collection from a .query directory.
+type queryDir struct {
+	reference string
+	matcher   query.Expression
+}
+
+// addQueryDir adds an empty .query directory to the collection rooted at
+// parent. Directories created inside it become views of the collection
+// filtered by matcher and the query in the directory name.
+func (fs *UorFs) addQueryDir(parent *UorFsNode, reference string, matcher query.Expression) {
+	// Owner writable so the kernel permits mkdir.
+	node := newNode(0, 0, fuse.S_IFDIR|00755, fs.euid, fs.egid)
+	node.query = &queryDir{reference: reference, matcher: matcher}
+	fs.insertNode(parent, queryViewDir, node)
+}
+
+// Mkdir creates a filtered view when called inside a .query directory.
+// The view is loaded from the registry without holding the filesystem
+// lock, so other operations are not stalled meanwhile.
+func (fs *UorFs) Mkdir(path string, mode uint32) (errc int) {
+	op := fs.startOp("Mkdir", path)
+	defer op.end(&errc)
+	dir, name := splitPath(path)
+
+	var parent *UorFsNode
+	var client registryclient.Remote
+	if errc := func() int {
+		defer op.synchronize()()
+		parent = fs.lookupNode(dir)
+		if parent == nil {
+			return -fuse.ENOENT
+		}
+		if parent.query == nil {
+			return -fuse.EROFS
+		}
+		if _, exists := parent.children[name]; exists {
+			return -fuse.EEXIST
+		}
+		client = fs.client
+		return 0
+	}(); errc != 0 {
+		return errc
+	}
+
+	viewQuery, err := parseQuery(name)
+	if err != nil {
+		fs.Logger.Errorf("invalid query %q: %v", name, err)
+		return -fuse.EINVAL
+	}
+	// Views narrow the collection they are created in.
+	matcher := query.And(parent.query.matcher, query.FromAttributes(viewQuery))
+
+	fs.Logger.Infof("Creating view %s of %s", name, parent.query.reference)
+	view := newNode(0, 0, fuse.S_IFDIR|00555, fs.euid, fs.egid)
+	if err := fs.loadFromReference(op.ctx, view, parent.query.reference, client, matcher, 0, nil); err != nil {
+		fs.Logger.Errorf("error creating view %q: %v", name, err)
+		return -fuse.EIO
+	}
+
+	defer op.synchronize()()
+	// The same view may have been created while this one loaded.
+	if _, exists := parent.children[name]; exists {
+		return -fuse.EEXIST
+	}
+	parent.children[name] = view
+	parent.stat.Nlink++
+	return 0
+}
+
+// Rmdir removes a view from a .query directory.
+func (fs *UorFs) Rmdir(path string) (errc int) {
+	op := fs.startOp("Rmdir", path)
+	defer op.end(&errc)
+	defer op.synchronize()()
+	dir, name := splitPath(path)
+	parent := fs.lookupNode(dir)
+	if parent == nil {
+		return -fuse.ENOENT
+	}
+	if parent.query == nil {
+		return -fuse.EROFS
+	}
+	if _, exists := parent.children[name]; !exists {
+		return -fuse.ENOENT
+	}
+	delete(parent.children, name)
+	parent.stat.Nlink--
+	return 0
+}
+
+// parseQuery parses a view name into a matcher. The name is either a JSON
+// object such as {"type":"model"} or URL query form such as
+// type=model&size=large. URL query values that are valid JSON, such as
+// numbers and booleans, keep their JSON type.
+func parseQuery(spec string) (matchers.PartialAttributeMatcher, error) {
+	values := map[string]interface{}{}
+	if strings.HasPrefix(strings.TrimSpace(spec), "{") {
+		if err := json.Unmarshal([]byte(spec), &values); err != nil {
+			return nil, err
+		}
+	} else {
+		form, err := url.ParseQuery(spec)
+		if err != nil {
+			return nil, err
+		}
+		for key, vals := range form {
+			if len(vals) != 1 {
+				return nil, fmt.Errorf("attribute %q given %d times", key, len(vals))
+			}
+			var value interface{}
+			if err := json.Unmarshal([]byte(vals[0]), &value); err != nil {
+				value = vals[0]
+			}
+			values[key] = value
+		}
+	}
+	if len(values) == 0 {
+		return nil, errors.New("empty query")
+	}
+
+	matcher := matchers.PartialAttributeMatcher{}
+	for key, value := range values {
+		attribute, err := attributes.Reflect(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", key, err)
+		}
+		matcher[key] = attribute
+	}
+	return matcher, nil
+}
+
+// splitPath splits a FUSE path into its parent directory and final name.
+func splitPath(path string) (string, string) {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/", path[i+1:]
+	}
+	return path[:i], path[i+1:]
+}
@@ -0,0 +1,59 @@
+package fs
+
+import "github.com/winfsp/cgofuse/fuse"
+
+// The mount is mounted with "-o ro" unless query views are enabled, in
+// which case views can be created with mkdir in .query directories. Every
+// other modification is refused here.
+
+func (fs *UorFs) Mknod(path string, mode uint32, dev uint64) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Unlink(path string) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Link(oldpath string, newpath string) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Symlink(target string, newpath string) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Rename(oldpath string, newpath string) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Chmod(path string, mode uint32) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Chown(path string, uid uint32, gid uint32) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Utimens(path string, tmsp []fuse.Timespec) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Create(path string, flags int, mode uint32) (int, uint64) {
+	return -fuse.EROFS, ^uint64(0)
+}
+
+func (fs *UorFs) Truncate(path string, size int64, fh uint64) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Setxattr(path string, name string, value []byte, flags int) int {
+	return -fuse.EROFS
+}
+
+func (fs *UorFs) Removexattr(path string, name string) int {
+	return -fuse.EROFS
+}
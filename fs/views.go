@@ -0,0 +1,83 @@
+package fs
+
+import (
+	"encoding/json"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/uor-framework/uor-client-go/model"
+)
+
+const (
+	// byAttributeDir is the virtual directory listing files by
+	// attribute key and value.
+	byAttributeDir = ".by-attribute"
+	// byDigestDir is the virtual directory listing every blob
+	// in a collection by digest.
+	byDigestDir = ".by-digest"
+)
+
+// viewNameReplacer escapes characters that cannot appear in a
+// single path component.
+var viewNameReplacer = strings.NewReplacer("%", "%25", "/", "%2F")
+
+// escapeName escapes name to a single reachable path component:
+// characters are escaped with viewNameReplacer, and the names "." and
+// "..", which refer to the directory and its parent, are percent-escaped
+// as a whole.
+func escapeName(name string) string {
+	switch name {
+	case ".":
+		return "%2E"
+	case "..":
+		return "%2E%2E"
+	}
+	return viewNameReplacer.Replace(name)
+}
+
+// indexAttributes links node into the .by-attribute view of the collection
+// rooted at parent as .by-attribute/KEY/VALUE/PATH.
+func (fs *UorFs) indexAttributes(parent *UorFsNode, path string, node *UorFsNode, attributeSet model.AttributeSet) {
+	for _, attribute := range attributeSet.List() {
+		if attribute.Key() == ocispec.AnnotationTitle {
+			continue
+		}
+		value, ok := attributeDirName(attribute)
+		if !ok {
+			continue
+		}
+		viewPath := strings.Join([]string{byAttributeDir, escapeName(attribute.Key()), value, path}, "/")
+		fs.insertNode(parent, viewPath, node)
+		node.stat.Nlink++
+	}
+}
+
+// indexDigest adds the blob described by desc to the .by-digest view of the
+// collection rooted at parent as .by-digest/ALGORITHM/HEX. Unlike titled
+// files this includes manifests, configs and schemas.
+func (fs *UorFs) indexDigest(parent *UorFsNode, reference string, desc ocispec.Descriptor) {
+	if err := desc.Digest.Validate(); err != nil {
+		fs.Logger.Debugf("skipping blob with invalid digest %q: %v", desc.Digest, err)
+		return
+	}
+	path := strings.Join([]string{byDigestDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded()}, "/")
+	if childAt(parent, path) != nil {
+		return
+	}
+	fs.insertNode(parent, path, fs.newBlobNode(reference, desc))
+}
+
+// attributeDirName returns the directory name for an attribute value.
+// String values are used as-is and other kinds use their JSON form.
+func attributeDirName(attribute model.Attribute) (string, bool) {
+	if attribute.Kind() == model.KindString {
+		if value, err := attribute.AsString(); err == nil && value != "" {
+			return escapeName(value), true
+		}
+	}
+	value, err := json.Marshal(attribute.AsAny())
+	if err != nil {
+		return "", false
+	}
+	return escapeName(string(value)), true
+}
@@ -0,0 +1,21 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// errXattrUnsupported is returned by setXattr when the filesystem or
+// platform does not support extended attributes.
+var errXattrUnsupported = errors.New("extended attributes are not supported")
+
+// setXattr sets the extended attribute name of the file at path.
+func setXattr(path string, name string, value []byte) error {
+	err := unix.Setxattr(path, name, value, 0)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return fmt.Errorf("%s: %w", path, errXattrUnsupported)
+	}
+	return err
+}
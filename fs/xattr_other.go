@@ -0,0 +1,14 @@
+//go:build !linux
+
+package fs
+
+import "errors"
+
+// errXattrUnsupported is returned by setXattr when the filesystem or
+// platform does not support extended attributes.
+var errXattrUnsupported = errors.New("extended attributes are not supported on this platform")
+
+// setXattr always fails: extended attributes are only set on Linux.
+func setXattr(path string, name string, value []byte) error {
+	return errXattrUnsupported
+}
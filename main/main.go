@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
+	"github.com/uor-framework/uor-fuse-go/cache"
 	"github.com/uor-framework/uor-fuse-go/cli"
 	"github.com/uor-framework/uor-fuse-go/cli/log"
 	"github.com/uor-framework/uor-fuse-go/config"
@@ -34,23 +36,27 @@ func NewRootCmd() *cobra.Command {
 		//Long:          clientLong,
 		SilenceErrors: false,
 		SilenceUsage:  false,
-		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
-			logger, err := log.NewLogger(o.IOStreams.Out, o.LogLevel)
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := o.ApplyConfig(cmd); err != nil {
+				return err
+			}
+
+			logger, err := log.NewLogger(o.IOStreams.Out, o.LogLevel, o.LogFormat)
 			if err != nil {
 				return err
 			}
 			o.Logger = logger
 
-			cacheEnv := os.Getenv("UOR_CACHE")
-			if cacheEnv != "" {
-				o.CacheDir = cacheEnv
-			} else {
+			if o.CacheDir == "" {
 				home, err := homedir.Dir()
 				if err != nil {
 					return err
 				}
 				o.CacheDir = filepath.Join(home, ".uor", "cache")
 			}
+			if _, err := cache.ParseSize(o.CacheMaxSize); o.CacheMaxSize != "" && err != nil {
+				return fmt.Errorf("invalid --cache-max-size: %w", err)
+			}
 
 			return os.MkdirAll(o.CacheDir, 0750)
 		},
@@ -62,8 +68,25 @@ func NewRootCmd() *cobra.Command {
 	f := cmd.PersistentFlags()
 	f.StringVarP(&o.LogLevel, "loglevel", "l", "info",
 		"Log level (debug, info, warn, error, fatal)")
+	f.StringVar(&o.LogFormat, "log-format", log.FormatText,
+		"Log format (text, json)")
+	f.StringVar(&o.CacheDir, "cache-dir", "",
+		"Blob cache directory (default ~/.uor/cache, env UOR_CACHE)")
+	f.StringVar(&o.CacheMaxSize, "cache-max-size", "10G",
+		"Size mounts keep the blob cache under by deleting the least recently used blobs, 0 for no limit (env UOR_CACHE_SIZE)")
+	f.StringVar(&o.ConfigFile, "config", "",
+		"Configuration file with flag defaults and profiles (default ~/.uor/fuse.yaml, env UOR_CONFIG)")
+	f.StringVar(&o.Profile, "profile", "",
+		"Profile of the configuration file to apply (env UOR_PROFILE)")
 
 	cmd.AddCommand(cli.NewMountCmd(&o))
+	cmd.AddCommand(cli.NewServeCmd(&o))
+	cmd.AddCommand(cli.NewPullCmd(&o))
+	cmd.AddCommand(cli.NewLsCmd(&o))
+	cmd.AddCommand(cli.NewCatCmd(&o))
+	cmd.AddCommand(cli.NewDiffCmd(&o))
+	cmd.AddCommand(cli.NewStatusCmd(&o))
+	cmd.AddCommand(cli.NewCacheCmd(&o))
 	cmd.AddCommand(cli.NewVersionCmd(&o))
 
 	return cmd
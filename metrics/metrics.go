@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "uor_fuse"
+
+// Metrics collects Prometheus metrics for a single mount. Every metric
+// carries a constant mount label. A nil *Metrics is valid and records
+// nothing, so callers do not need to check whether metrics are enabled.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	ops         *prometheus.CounterVec
+	opDuration  *prometheus.HistogramVec
+	bytesServed prometheus.Counter
+
+	fetches       *prometheus.CounterVec
+	fetchDuration *prometheus.HistogramVec
+
+	cacheHits      prometheus.Counter
+	cacheMisses    prometheus.Counter
+	cacheEvictions prometheus.Counter
+	cacheBytes     prometheus.Gauge
+}
+
+// New returns Metrics for the mount at mountPoint registered
+// in a new registry, along with Go runtime and process metrics.
+func New(mountPoint string) *Metrics {
+	labels := prometheus.Labels{"mount": mountPoint}
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		ops: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "operations_total",
+			Help:        "FUSE operations by operation and result errno (0 on success).",
+			ConstLabels: labels,
+		}, []string{"op", "errno"}),
+		opDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "operation_duration_seconds",
+			Help:        "Latency of FUSE operations, including time waiting for the filesystem lock.",
+			ConstLabels: labels,
+			Buckets:     prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"op"}),
+		bytesServed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "read_bytes_total",
+			Help:        "Bytes returned by Read.",
+			ConstLabels: labels,
+		}),
+		fetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "registry_fetches_total",
+			Help:        "Registry requests by kind and result.",
+			ConstLabels: labels,
+		}, []string{"kind", "result"}),
+		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "registry_fetch_duration_seconds",
+			Help:        "Latency of registry requests by kind.",
+			ConstLabels: labels,
+			Buckets:     prometheus.ExponentialBuckets(0.005, 2, 12),
+		}, []string{"kind"}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "cache_hits_total",
+			Help:        "Reads served from cached blob content.",
+			ConstLabels: labels,
+		}),
+		cacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "cache_misses_total",
+			Help:        "Reads that fetched blob content from the registry.",
+			ConstLabels: labels,
+		}),
+		cacheEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "cache_evictions_total",
+			Help:        "Cached blobs flushed after their decay timer expired.",
+			ConstLabels: labels,
+		}),
+		cacheBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "cache_resident_bytes",
+			Help:        "Bytes of blob content currently held in memory.",
+			ConstLabels: labels,
+		}),
+	}
+	m.registry.MustRegister(
+		m.ops, m.opDuration, m.bytesServed,
+		m.fetches, m.fetchDuration,
+		m.cacheHits, m.cacheMisses, m.cacheEvictions, m.cacheBytes,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// ObserveOp records a FUSE operation that took duration and returned
+// errc, a negative errno on failure.
+func (m *Metrics) ObserveOp(op string, duration time.Duration, errc int) {
+	if m == nil {
+		return
+	}
+	errno := 0
+	if errc < 0 {
+		errno = -errc
+	}
+	m.ops.WithLabelValues(op, strconv.Itoa(errno)).Inc()
+	m.opDuration.WithLabelValues(op).Observe(duration.Seconds())
+}
+
+// AddBytesServed records n bytes returned to a reader.
+func (m *Metrics) AddBytesServed(n int) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.bytesServed.Add(float64(n))
+}
+
+// ObserveFetch records a registry request of kind that took duration.
+func (m *Metrics) ObserveFetch(kind string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.fetches.WithLabelValues(kind, result).Inc()
+	m.fetchDuration.WithLabelValues(kind).Observe(duration.Seconds())
+}
+
+// CacheHit records a read served from cache.
+func (m *Metrics) CacheHit() {
+	if m == nil {
+		return
+	}
+	m.cacheHits.Inc()
+}
+
+// CacheMiss records a read that had to fetch content.
+func (m *Metrics) CacheMiss() {
+	if m == nil {
+		return
+	}
+	m.cacheMisses.Inc()
+}
+
+// CacheAdd records size bytes of content entering the cache.
+func (m *Metrics) CacheAdd(size int) {
+	if m == nil {
+		return
+	}
+	m.cacheBytes.Add(float64(size))
+}
+
+// CacheEvict records size bytes of content leaving the cache.
+func (m *Metrics) CacheEvict(size int) {
+	if m == nil {
+		return
+	}
+	m.cacheEvictions.Inc()
+	m.cacheBytes.Sub(float64(size))
+}
+
+// Handler returns an HTTP handler exposing the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve exposes the metrics on /metrics at addr until ctx is done.
+func (m *Metrics) Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
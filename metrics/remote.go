@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/uor-framework/uor-client-go/nodes/collection"
+	"github.com/uor-framework/uor-client-go/registryclient"
+)
+
+// InstrumentRemote returns a client that records the latency and result
+// of the registry requests made through remote. It returns remote
+// unchanged when m is nil.
+func (m *Metrics) InstrumentRemote(remote registryclient.Remote) registryclient.Remote {
+	if m == nil || remote == nil {
+		return remote
+	}
+	if _, ok := remote.(*instrumentedRemote); ok {
+		return remote
+	}
+	return &instrumentedRemote{Remote: remote, metrics: m}
+}
+
+type instrumentedRemote struct {
+	registryclient.Remote
+	metrics *Metrics
+}
+
+func (r *instrumentedRemote) GetManifest(ctx context.Context, reference string) (ocispec.Descriptor, io.ReadCloser, error) {
+	start := time.Now()
+	desc, rc, err := r.Remote.GetManifest(ctx, reference)
+	r.metrics.ObserveFetch("manifest", time.Since(start), err)
+	return desc, rc, err
+}
+
+func (r *instrumentedRemote) GetContent(ctx context.Context, reference string, desc ocispec.Descriptor) ([]byte, error) {
+	start := time.Now()
+	content, err := r.Remote.GetContent(ctx, reference, desc)
+	r.metrics.ObserveFetch("content", time.Since(start), err)
+	return content, err
+}
+
+func (r *instrumentedRemote) LoadCollection(ctx context.Context, reference string) (collection.Collection, error) {
+	start := time.Now()
+	c, err := r.Remote.LoadCollection(ctx, reference)
+	r.metrics.ObserveFetch("collection", time.Since(start), err)
+	return c, err
+}
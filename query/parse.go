@@ -0,0 +1,348 @@
+package query
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parse parses an attribute query expression such as
+//
+//	size>1000 AND type=~"^img"
+//
+// Comparisons take the form KEY OP VALUE where OP is one of =, !=, <, <=,
+// >, >=, =~ (regular expression), !~ (negated regular expression) or GLOB.
+// KEY IN LOW..HIGH matches numbers in an inclusive range and EXISTS KEY
+// matches nodes that have the attribute. Expressions are combined with
+// AND, OR, NOT and parentheses. Keywords are case insensitive.
+//
+// Values are quoted strings, numbers, true, false, null or bare words,
+// which are treated as strings. Keys containing characters other than
+// letters, digits and _.-/: must be quoted.
+func Parse(input string) (Expression, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", tok, tok.pos)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenRange
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+// keyword reports whether the token is the given case-insensitive keyword.
+func (t token) keyword(word string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, word)
+}
+
+var keywords = []string{"AND", "OR", "NOT", "EXISTS", "IN", "GLOB"}
+
+func isKeyword(word string) bool {
+	for _, keyword := range keywords {
+		if strings.EqualFold(word, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-/:", r)
+}
+
+func isBareWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == '"':
+			start := i
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+			text, err := strconv.Unquote(string(runes[start:i]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %w", start, err)
+			}
+			tokens = append(tokens, token{tokenString, text, start})
+		case strings.ContainsRune("=!<>", r):
+			start := i
+			op := string(r)
+			if i+1 < len(runes) && strings.ContainsRune("=~", runes[i+1]) {
+				op += string(runes[i+1])
+			}
+			switch operator(op) {
+			case opEqual, opNotEqual, opLess, opLessEqual, opGreater, opGreaterEqual, opRegexp, opNotRegexp:
+			default:
+				return nil, fmt.Errorf("unknown operator %q at offset %d", op, start)
+			}
+			i += len(op)
+			tokens = append(tokens, token{tokenOperator, op, start})
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			// Split ranges written without spaces, such as 10..20.
+			if low, high, found := strings.Cut(word, ".."); found {
+				if low != "" {
+					tokens = append(tokens, token{tokenWord, low, start})
+				}
+				tokens = append(tokens, token{tokenRange, "..", start + len([]rune(low))})
+				if high != "" {
+					tokens = append(tokens, token{tokenWord, high, start + len([]rune(low)) + 2})
+				}
+				continue
+			}
+			tokens = append(tokens, token{tokenWord, word, start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", r, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	operands := or{left}
+	for p.peek().keyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return operands, nil
+}
+
+func (p *parser) parseAnd() (Expression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []Expression{left}
+	for p.peek().keyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+	return And(exprs...), nil
+}
+
+func (p *parser) parseNot() (Expression, error) {
+	if p.peek().keyword("NOT") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return not{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expression, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ')' at offset %d, found %s", closing.pos, closing)
+		}
+		return expr, nil
+	case tok.keyword("EXISTS"):
+		key, err := p.parseKey(p.next())
+		if err != nil {
+			return nil, err
+		}
+		return exists{key}, nil
+	}
+
+	key, err := p.parseKey(tok)
+	if err != nil {
+		return nil, err
+	}
+	opTok := p.next()
+	switch {
+	case opTok.keyword("IN"):
+		return p.parseRange(key)
+	case opTok.keyword("GLOB"):
+		pattern := p.next()
+		if pattern.kind != tokenString && pattern.kind != tokenWord {
+			return nil, fmt.Errorf("expected pattern at offset %d, found %s", pattern.pos, pattern)
+		}
+		if _, err := path.Match(pattern.text, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern.text, err)
+		}
+		return &comparison{key: key, op: opGlob, value: pattern.text}, nil
+	case opTok.kind != tokenOperator:
+		return nil, fmt.Errorf("expected operator after %q at offset %d, found %s", key, opTok.pos, opTok)
+	}
+
+	valueTok := p.next()
+	c := &comparison{key: key, op: operator(opTok.text)}
+	switch c.op {
+	case opRegexp, opNotRegexp:
+		if valueTok.kind != tokenString && valueTok.kind != tokenWord {
+			return nil, fmt.Errorf("expected regular expression at offset %d, found %s", valueTok.pos, valueTok)
+		}
+		re, err := regexp.Compile(valueTok.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", valueTok.text, err)
+		}
+		c.value, c.regexp = valueTok.text, re
+	default:
+		value, err := parseValue(valueTok)
+		if err != nil {
+			return nil, err
+		}
+		c.value = value
+	}
+	return c, nil
+}
+
+func (p *parser) parseKey(tok token) (string, error) {
+	switch {
+	case tok.kind == tokenString:
+		return tok.text, nil
+	case tok.kind == tokenWord && !isKeyword(tok.text):
+		return tok.text, nil
+	}
+	return "", fmt.Errorf("expected attribute key at offset %d, found %s", tok.pos, tok)
+}
+
+func (p *parser) parseRange(key string) (Expression, error) {
+	lowTok := p.next()
+	if rangeTok := p.next(); rangeTok.kind != tokenRange {
+		return nil, fmt.Errorf("expected '..' at offset %d, found %s", rangeTok.pos, rangeTok)
+	}
+	highTok := p.next()
+
+	low, err := strconv.ParseFloat(lowTok.text, 64)
+	if err != nil || lowTok.kind != tokenWord {
+		return nil, fmt.Errorf("expected number at offset %d, found %s", lowTok.pos, lowTok)
+	}
+	high, err := strconv.ParseFloat(highTok.text, 64)
+	if err != nil || highTok.kind != tokenWord {
+		return nil, fmt.Errorf("expected number at offset %d, found %s", highTok.pos, highTok)
+	}
+	return between{key: key, low: low, high: high, lowText: lowTok.text, highText: highTok.text}, nil
+}
+
+// parseValue converts a value token to the Go type used by attributes.
+func parseValue(tok token) (interface{}, error) {
+	switch tok.kind {
+	case tokenString:
+		return tok.text, nil
+	case tokenWord:
+	default:
+		return nil, fmt.Errorf("expected value at offset %d, found %s", tok.pos, tok)
+	}
+
+	switch strings.ToLower(tok.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if i, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(tok.text, 64); err == nil {
+		return f, nil
+	}
+	return tok.text, nil
+}
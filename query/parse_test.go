@@ -0,0 +1,203 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{name: "Empty", input: "", err: "expected attribute key at offset 0, found end of query"},
+		{name: "UnterminatedString", input: `type="img`, err: "unterminated string at offset 5"},
+		{name: "UnknownOperator", input: "size=!1", err: `unknown operator "!" at offset 5`},
+		{name: "OperatorAsValue", input: "size<>1", err: `expected value at offset 5, found ">"`},
+		{name: "UnexpectedCharacter", input: "size>1 & type=img", err: `unexpected character '&' at offset 7`},
+		{name: "MissingOperator", input: "size 1", err: `expected operator after "size" at offset 5, found "1"`},
+		{name: "MissingValue", input: "size>", err: "expected value at offset 5, found end of query"},
+		{name: "KeywordAsKey", input: "AND=1", err: `expected attribute key at offset 0, found "AND"`},
+		{name: "UnclosedParen", input: "(size>1", err: "expected ')' at offset 7, found end of query"},
+		{name: "TrailingTokens", input: "size>1 type=img", err: `unexpected "type" at offset 7`},
+		{name: "DanglingAnd", input: "size>1 AND", err: "expected attribute key at offset 10, found end of query"},
+		{name: "InvalidRegexp", input: `name=~"("`, err: `invalid regular expression "("`},
+		{name: "InvalidGlob", input: `name GLOB "["`, err: `invalid pattern "["`},
+		{name: "RangeWithoutDots", input: "size IN 1 2", err: `expected '..' at offset 10, found "2"`},
+		{name: "RangeNotNumber", input: "size IN a..2", err: `expected number at offset 8, found "a"`},
+		{name: "RangeQuotedBound", input: `size IN 1.."2"`, err: `expected number at offset 11, found "2"`},
+		{name: "ExistsWithoutKey", input: "EXISTS", err: "expected attribute key at offset 6, found end of query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err == nil {
+				t.Fatalf("Parse(%q) = %s, want error", tt.input, expr)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse(%q) error = %q, want %q", tt.input, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Comparison", input: "size>1000", want: "size > 1000"},
+		{name: "BareWordValue", input: "type=model", want: `type = "model"`},
+		{name: "QuotedKey", input: `"my key"="a b"`, want: `"my key" = "a b"`},
+		{name: "KeywordKeyQuoted", input: `"and"=1`, want: `"and" = 1`},
+		{name: "KeyWithRange", input: `"a..b"=1`, want: `"a..b" = 1`},
+		{name: "Escapes", input: `name="a\"b"`, want: `name = "a\"b"`},
+		{name: "Literals", input: "a=true AND b=FALSE AND c=null AND d=1.5", want: "a = true AND b = false AND c = null AND d = 1.5"},
+		{name: "LargeFloat", input: "size>1e21", want: "size > 1000000000000000000000"},
+		{name: "SmallFloat", input: "ratio<1e-7", want: "ratio < 0.0000001"},
+		{name: "Regexp", input: `type=~"^img" AND name!~tmp`, want: `type =~ "^img" AND name !~ "tmp"`},
+		{name: "Glob", input: `name glob "*.txt"`, want: `name GLOB "*.txt"`},
+		{name: "Range", input: "size IN 10..20", want: "size IN 10..20"},
+		{name: "RangeSpaced", input: "size in 10 .. 20.5", want: "size IN 10..20.5"},
+		{name: "Exists", input: "exists type", want: "EXISTS type"},
+		{name: "KeywordsCaseInsensitive", input: "not a=1 or b=2 and c=3", want: "NOT a = 1 OR b = 2 AND c = 3"},
+		{name: "AndBindsTighterThanOr", input: "a=1 OR b=2 AND c=3", want: "a = 1 OR b = 2 AND c = 3"},
+		{name: "ParensOverridePrecedence", input: "(a=1 OR b=2) AND c=3", want: "(a = 1 OR b = 2) AND c = 3"},
+		{name: "NestedAndFlattened", input: "a=1 AND (b=2 AND c=3)", want: "a = 1 AND b = 2 AND c = 3"},
+		{name: "NotOfGroup", input: "NOT (a=1 OR b=2)", want: "NOT (a = 1 OR b = 2)"},
+		{name: "DoubleNot", input: "NOT NOT a=1", want: "NOT NOT a = 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if got := expr.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			// Formatted expressions parse back to the same expression.
+			again, err := Parse(expr.String())
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", expr.String(), err)
+			}
+			if again.String() != expr.String() {
+				t.Errorf("Parse(%q) = %q, want it unchanged", expr.String(), again.String())
+			}
+		})
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Expression
+	}{
+		{
+			name:  "AndBeforeOr",
+			input: "a=1 OR b=2 AND c=3",
+			want: or{
+				&comparison{key: "a", op: opEqual, value: int64(1)},
+				and{
+					&comparison{key: "b", op: opEqual, value: int64(2)},
+					&comparison{key: "c", op: opEqual, value: int64(3)},
+				},
+			},
+		},
+		{
+			name:  "NotBeforeAnd",
+			input: "NOT a=1 AND b=2",
+			want: and{
+				not{&comparison{key: "a", op: opEqual, value: int64(1)}},
+				&comparison{key: "b", op: opEqual, value: int64(2)},
+			},
+		},
+		{
+			name:  "Parens",
+			input: "NOT (a=1 OR b=2)",
+			want: not{or{
+				&comparison{key: "a", op: opEqual, value: int64(1)},
+				&comparison{key: "b", op: opEqual, value: int64(2)},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if expr.String() != tt.want.String() {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, expr, tt.want)
+			}
+			if !sameShape(expr, tt.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tt.input, expr, tt.want)
+			}
+		})
+	}
+}
+
+// sameShape reports whether a and b have the same operators nested the
+// same way, with the same comparisons as leaves.
+func sameShape(a, b Expression) bool {
+	switch a := a.(type) {
+	case and:
+		b, ok := b.(and)
+		return ok && sameOperands(a, b)
+	case or:
+		b, ok := b.(or)
+		return ok && sameOperands(a, b)
+	case not:
+		b, ok := b.(not)
+		return ok && sameShape(a.operand, b.operand)
+	}
+	return a.String() == b.String()
+}
+
+func sameOperands(a, b []Expression) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameShape(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{input: "a=1", want: int64(1)},
+		{input: "a=-3", want: int64(-3)},
+		{input: "a=1.5", want: 1.5},
+		{input: "a=1e3", want: 1000.0},
+		{input: `a="1"`, want: "1"},
+		{input: "a=True", want: true},
+		{input: "a=false", want: false},
+		{input: "a=NULL", want: nil},
+		{input: `a="true"`, want: "true"},
+		{input: "a=v1.2.3", want: "v1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			c, ok := expr.(*comparison)
+			if !ok {
+				t.Fatalf("Parse(%q) = %T, want a comparison", tt.input, expr)
+			}
+			if c.value != tt.want {
+				t.Errorf("Parse(%q) value = %#v, want %#v", tt.input, c.value, tt.want)
+			}
+		})
+	}
+}
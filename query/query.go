@@ -0,0 +1,315 @@
+package query
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uor-framework/uor-client-go/model"
+)
+
+// Expression is a parsed attribute query. Expressions match
+// collection nodes by their attributes.
+type Expression interface {
+	model.Matcher
+	// String returns the expression in query syntax.
+	String() string
+}
+
+// Format returns expr in query syntax, or an empty string if expr is nil.
+func Format(expr Expression) string {
+	if expr == nil {
+		return ""
+	}
+	return expr.String()
+}
+
+// And returns an expression matching nodes that match every non-nil
+// expression in exprs. It returns nil if there are none.
+func And(exprs ...Expression) Expression {
+	var operands []Expression
+	for _, expr := range exprs {
+		switch e := expr.(type) {
+		case nil:
+		case and:
+			operands = append(operands, e...)
+		default:
+			operands = append(operands, e)
+		}
+	}
+	switch len(operands) {
+	case 0:
+		return nil
+	case 1:
+		return operands[0]
+	}
+	return and(operands)
+}
+
+// FromAttributes returns an expression requiring each attribute in
+// attributes to be present with an equal value. This is the behaviour
+// of matchers.PartialAttributeMatcher.
+func FromAttributes(attributes map[string]model.Attribute) Expression {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	exprs := make([]Expression, 0, len(keys))
+	for _, key := range keys {
+		exprs = append(exprs, &comparison{key: key, op: opEqual, value: attributes[key].AsAny()})
+	}
+	return And(exprs...)
+}
+
+type and []Expression
+
+func (e and) Matches(n model.Node) (bool, error) {
+	for _, operand := range e {
+		match, err := operand.Matches(n)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (e and) String() string {
+	parts := make([]string, 0, len(e))
+	for _, operand := range e {
+		if _, isOr := operand.(or); isOr {
+			parts = append(parts, "("+operand.String()+")")
+			continue
+		}
+		parts = append(parts, operand.String())
+	}
+	return strings.Join(parts, " AND ")
+}
+
+type or []Expression
+
+func (e or) Matches(n model.Node) (bool, error) {
+	for _, operand := range e {
+		match, err := operand.Matches(n)
+		if err != nil || match {
+			return match, err
+		}
+	}
+	return false, nil
+}
+
+func (e or) String() string {
+	parts := make([]string, 0, len(e))
+	for _, operand := range e {
+		parts = append(parts, operand.String())
+	}
+	return strings.Join(parts, " OR ")
+}
+
+type not struct {
+	operand Expression
+}
+
+func (e not) Matches(n model.Node) (bool, error) {
+	match, err := e.operand.Matches(n)
+	return !match, err
+}
+
+func (e not) String() string {
+	switch e.operand.(type) {
+	case and, or:
+		return "NOT (" + e.operand.String() + ")"
+	}
+	return "NOT " + e.operand.String()
+}
+
+type exists struct {
+	key string
+}
+
+func (e exists) Matches(n model.Node) (bool, error) {
+	return find(n, e.key) != nil, nil
+}
+
+func (e exists) String() string {
+	return "EXISTS " + formatKey(e.key)
+}
+
+type operator string
+
+const (
+	opEqual        operator = "="
+	opNotEqual     operator = "!="
+	opLess         operator = "<"
+	opLessEqual    operator = "<="
+	opGreater      operator = ">"
+	opGreaterEqual operator = ">="
+	opRegexp       operator = "=~"
+	opNotRegexp    operator = "!~"
+	opGlob         operator = "GLOB"
+)
+
+// comparison compares an attribute with a value. Comparisons against
+// attributes a node does not have never match.
+type comparison struct {
+	key    string
+	op     operator
+	value  interface{}
+	regexp *regexp.Regexp
+}
+
+func (c *comparison) Matches(n model.Node) (bool, error) {
+	attribute := find(n, c.key)
+	if attribute == nil {
+		return false, nil
+	}
+	actual := attribute.AsAny()
+
+	switch c.op {
+	case opEqual:
+		return equal(actual, c.value), nil
+	case opNotEqual:
+		return !equal(actual, c.value), nil
+	case opLess, opLessEqual, opGreater, opGreaterEqual:
+		order, ok := compare(actual, c.value)
+		if !ok {
+			return false, nil
+		}
+		switch c.op {
+		case opLess:
+			return order < 0, nil
+		case opLessEqual:
+			return order <= 0, nil
+		case opGreater:
+			return order > 0, nil
+		default:
+			return order >= 0, nil
+		}
+	case opRegexp, opNotRegexp:
+		s, ok := actual.(string)
+		if !ok {
+			return false, nil
+		}
+		return c.regexp.MatchString(s) == (c.op == opRegexp), nil
+	case opGlob:
+		s, ok := actual.(string)
+		if !ok {
+			return false, nil
+		}
+		return path.Match(c.value.(string), s)
+	}
+	return false, fmt.Errorf("unknown operator %s", c.op)
+}
+
+func (c *comparison) String() string {
+	return formatKey(c.key) + " " + string(c.op) + " " + formatValue(c.value)
+}
+
+// between matches numeric attributes within an inclusive range.
+type between struct {
+	key      string
+	low      float64
+	high     float64
+	lowText  string
+	highText string
+}
+
+func (b between) Matches(n model.Node) (bool, error) {
+	attribute := find(n, b.key)
+	if attribute == nil {
+		return false, nil
+	}
+	value, ok := number(attribute.AsAny())
+	if !ok {
+		return false, nil
+	}
+	return value >= b.low && value <= b.high, nil
+}
+
+func (b between) String() string {
+	return formatKey(b.key) + " IN " + b.lowText + ".." + b.highText
+}
+
+// find returns the attribute of n with key, or nil.
+func find(n model.Node, key string) model.Attribute {
+	attributes := n.Attributes()
+	if attributes == nil {
+		return nil
+	}
+	return attributes.Find(key)
+}
+
+func equal(actual, expected interface{}) bool {
+	if a, ok := number(actual); ok {
+		e, ok := number(expected)
+		return ok && a == e
+	}
+	return actual == expected
+}
+
+// compare orders two numbers or two strings.
+func compare(actual, expected interface{}) (int, bool) {
+	if a, ok := number(actual); ok {
+		e, ok := number(expected)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a < e:
+			return -1, true
+		case a > e:
+			return 1, true
+		}
+		return 0, true
+	}
+	a, ok := actual.(string)
+	if !ok {
+		return 0, false
+	}
+	e, ok := expected.(string)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(a, e), true
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// formatKey quotes key unless it lexes back as a single word, which
+// keywords and keys containing ".." (lexed as a range) do not.
+func formatKey(key string) string {
+	if isBareWord(key) && !isKeyword(key) && !strings.Contains(key, "..") {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// formatValue formats value as the lexer reads it back. Floats are
+// written without exponent, which the lexer does not accept.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,214 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/uor-framework/uor-client-go/attributes"
+	"github.com/uor-framework/uor-client-go/model"
+)
+
+// testNode is a node with attributes and nothing else.
+type testNode struct {
+	attributes model.AttributeSet
+}
+
+func (n testNode) ID() string                     { return "test" }
+func (n testNode) Address() string                { return "test" }
+func (n testNode) Attributes() model.AttributeSet { return n.attributes }
+
+func newTestNode() model.Node {
+	return testNode{attributes: attributes.Attributes{
+		"name":   attributes.NewString("name", "fish.jpg"),
+		"type":   attributes.NewString("type", "image"),
+		"size":   attributes.NewInt("size", 1500),
+		"ratio":  attributes.NewFloat("ratio", 0.75),
+		"public": attributes.NewBool("public", true),
+		"owner":  attributes.NewNull("owner"),
+		"count":  attributes.NewString("count", "10"),
+	}}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		// Strings.
+		{name: "StringEqual", query: `type="image"`, want: true},
+		{name: "StringBareWord", query: "type=image", want: true},
+		{name: "StringNotEqual", query: "type!=image", want: false},
+		{name: "StringOrder", query: `name<"g"`, want: true},
+		{name: "StringOrderGreater", query: `name>="g"`, want: false},
+		{name: "StringRegexp", query: `name=~"\\.jpg$"`, want: true},
+		{name: "StringNotRegexp", query: `name!~"^fish"`, want: false},
+		{name: "StringGlob", query: `name GLOB "*.jpg"`, want: true},
+		{name: "StringGlobNoMatch", query: `name GLOB "*.png"`, want: false},
+		// A string holding digits is not a number.
+		{name: "NumericStringNotEqualNumber", query: "count=10", want: false},
+		{name: "NumericStringEqualString", query: `count="10"`, want: true},
+		{name: "NumericStringNotOrderedAsNumber", query: "count>9", want: false},
+		{name: "NumericStringOrderedAsString", query: `count<"9"`, want: true},
+		{name: "NumericStringNotInRange", query: "count IN 1..20", want: false},
+
+		// Integers.
+		{name: "IntEqual", query: "size=1500", want: true},
+		{name: "IntEqualFloat", query: "size=1500.0", want: true},
+		{name: "IntNotEqual", query: "size!=1500", want: false},
+		{name: "IntLess", query: "size<2000", want: true},
+		{name: "IntLessEqual", query: "size<=1500", want: true},
+		{name: "IntGreater", query: "size>1500", want: false},
+		{name: "IntGreaterEqual", query: "size>=1500", want: true},
+		{name: "IntInRange", query: "size IN 1000..1500", want: true},
+		{name: "IntOutOfRange", query: "size IN 1501..2000", want: false},
+		{name: "IntNotString", query: `size="1500"`, want: false},
+		{name: "IntNotOrderedAgainstString", query: `size>"1"`, want: false},
+		{name: "IntNoRegexp", query: `size=~"15"`, want: false},
+		{name: "IntNoGlob", query: `size GLOB "1*"`, want: false},
+
+		// Floats.
+		{name: "FloatEqual", query: "ratio=0.75", want: true},
+		{name: "FloatLess", query: "ratio<1", want: true},
+		{name: "FloatGreater", query: "ratio>0.8", want: false},
+		{name: "FloatInRange", query: "ratio IN 0.5..1", want: true},
+
+		// Booleans.
+		{name: "BoolEqual", query: "public=true", want: true},
+		{name: "BoolNotEqual", query: "public!=true", want: false},
+		{name: "BoolNotString", query: `public="true"`, want: false},
+		{name: "BoolNotOrdered", query: "public>false", want: false},
+
+		// Null.
+		{name: "NullEqual", query: "owner=null", want: true},
+		{name: "NullNotEqualString", query: `owner="null"`, want: false},
+		{name: "NullExists", query: "EXISTS owner", want: true},
+
+		// Missing attributes never match a comparison.
+		{name: "MissingEqual", query: "color=red", want: false},
+		{name: "MissingNotEqual", query: "color!=red", want: false},
+		{name: "MissingRange", query: "color IN 1..2", want: false},
+		{name: "MissingExists", query: "EXISTS color", want: false},
+		{name: "MissingNotExists", query: "NOT EXISTS color", want: true},
+
+		// Combinations.
+		{name: "And", query: "type=image AND size>1000", want: true},
+		{name: "AndOneFalse", query: "type=image AND size>2000", want: false},
+		{name: "Or", query: "type=model OR size>1000", want: true},
+		{name: "OrNoneTrue", query: "type=model OR size>2000", want: false},
+		{name: "Not", query: "NOT type=model", want: true},
+		{name: "AndBeforeOr", query: "type=image OR type=model AND size>2000", want: true},
+		{name: "ParensBeforeAnd", query: "(type=image OR type=model) AND size>2000", want: false},
+		{name: "NotBeforeAnd", query: "NOT type=model AND size>2000", want: false},
+		{name: "NotOfGroup", query: "NOT (type=model AND size>2000)", want: true},
+	}
+	node := newTestNode()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			got, err := expr.Matches(node)
+			if err != nil {
+				t.Fatalf("Matches(%q): %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesNoAttributes(t *testing.T) {
+	expr, err := Parse("NOT EXISTS type")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := expr.Matches(testNode{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got {
+		t.Error("NOT EXISTS type does not match a node without attributes")
+	}
+}
+
+func TestAnd(t *testing.T) {
+	a := &comparison{key: "a", op: opEqual, value: int64(1)}
+	b := &comparison{key: "b", op: opEqual, value: int64(2)}
+	tests := []struct {
+		name  string
+		exprs []Expression
+		want  string
+	}{
+		{name: "None", exprs: nil, want: ""},
+		{name: "OnlyNil", exprs: []Expression{nil, nil}, want: ""},
+		{name: "One", exprs: []Expression{nil, a}, want: "a = 1"},
+		{name: "Two", exprs: []Expression{a, b}, want: "a = 1 AND b = 2"},
+		{name: "Flattened", exprs: []Expression{And(a, b), a}, want: "a = 1 AND b = 2 AND a = 1"},
+		{name: "OrParenthesized", exprs: []Expression{or{a, b}, a}, want: "(a = 1 OR b = 2) AND a = 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Format(And(tt.exprs...)); got != tt.want {
+				t.Errorf("And() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromAttributes(t *testing.T) {
+	expr := FromAttributes(map[string]model.Attribute{
+		"type": attributes.NewString("type", "image"),
+		"size": attributes.NewInt("size", 1500),
+	})
+	if got, want := expr.String(), `size = 1500 AND type = "image"`; got != want {
+		t.Errorf("FromAttributes() = %q, want %q", got, want)
+	}
+	match, err := expr.Matches(newTestNode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Error("FromAttributes() does not match a node with those attributes")
+	}
+}
+
+// TestFormatRoundTrip checks that formatted expressions, which
+// reloadFilter compares, parse back to the same expression.
+func TestFormatRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		expr Expression
+		want string
+	}{
+		{name: "LargeFloat", expr: &comparison{key: "size", op: opGreater, value: 1e21}, want: "size > 1000000000000000000000"},
+		{name: "SmallFloat", expr: &comparison{key: "ratio", op: opLess, value: 1e-7}, want: "ratio < 0.0000001"},
+		{name: "NegativeFloat", expr: &comparison{key: "delta", op: opEqual, value: -2.5}, want: "delta = -2.5"},
+		{name: "KeyWithRange", expr: &comparison{key: "a..b", op: opEqual, value: "x"}, want: `"a..b" = "x"`},
+		{name: "KeywordKey", expr: &comparison{key: "or", op: opEqual, value: int64(1)}, want: `"or" = 1`},
+		{name: "KeyWithSpace", expr: exists{key: "my key"}, want: `EXISTS "my key"`},
+		{
+			name: "FromAttributes",
+			expr: FromAttributes(map[string]model.Attribute{
+				"size..max": attributes.NewFloat("size..max", 1e22),
+			}),
+			want: `"size..max" = 10000000000000000000000`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted := Format(tt.expr)
+			if formatted != tt.want {
+				t.Errorf("Format() = %q, want %q", formatted, tt.want)
+			}
+			again, err := Parse(formatted)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", formatted, err)
+			}
+			if Format(again) != formatted {
+				t.Errorf("Parse(%q) = %q, want it unchanged", formatted, Format(again))
+			}
+		})
+	}
+}
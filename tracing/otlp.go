@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The clients below plug into the upstream otlptrace exporter, which
+// converts spans to OTLP. otlptracehttp is not used because it pulls in
+// gRPC for its error types; a TracesData message is wire compatible with
+// the ExportTraceServiceRequest it sends.
+
+// httpClient posts spans as OTLP/HTTP protobuf to url.
+type httpClient struct {
+	url    string
+	client *http.Client
+}
+
+func (c *httpClient) Start(context.Context) error { return nil }
+
+func (c *httpClient) Stop(context.Context) error { return nil }
+
+func (c *httpClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	body, err := proto.Marshal(&tracepb.TracesData{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("trace collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// fileClient appends each batch of spans to a file as one line of
+// OTLP/JSON, as read by the collector's otlpjsonfile receiver.
+type fileClient struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (c *fileClient) Start(context.Context) error { return nil }
+
+func (c *fileClient) Stop(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.Close()
+}
+
+func (c *fileClient) UploadTraces(_ context.Context, spans []*tracepb.ResourceSpans) error {
+	line, err := marshalJSON(&tracepb.TracesData{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
+
+// marshalJSON encodes traces as OTLP/JSON. It differs from the protobuf
+// JSON mapping in that enums are numbers and trace and span IDs are hex
+// rather than base64.
+func marshalJSON(traces *tracepb.TracesData) ([]byte, error) {
+	encoded, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(traces)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var request map[string]interface{}
+	if err := decoder.Decode(&request); err != nil {
+		return nil, err
+	}
+	for _, rs := range objects(request["resourceSpans"]) {
+		for _, ss := range objects(rs["scopeSpans"]) {
+			for _, span := range objects(ss["spans"]) {
+				if err := hexIDs(span, "traceId", "spanId", "parentSpanId"); err != nil {
+					return nil, err
+				}
+				for _, link := range objects(span["links"]) {
+					if err := hexIDs(link, "traceId", "spanId"); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	return json.Marshal(request)
+}
+
+// objects returns the JSON objects in the array v.
+func objects(v interface{}) []map[string]interface{} {
+	array, _ := v.([]interface{})
+	var objects []map[string]interface{}
+	for _, element := range array {
+		if object, ok := element.(map[string]interface{}); ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// hexIDs re-encodes the base64 values of keys in object as hex.
+func hexIDs(object map[string]interface{}, keys ...string) error {
+	for _, key := range keys {
+		value, ok := object[key].(string)
+		if !ok {
+			continue
+		}
+		id, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		object[key] = hex.EncodeToString(id)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// testSpans returns two resources, two scopes, a root and a child span,
+// each attribute type, events and each status code.
+func testSpans() []sdktrace.ReadOnlySpan {
+	traceID := trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	rootID := trace.SpanID{0xa1, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7, 0xa8}
+	childID := trace.SpanID{0xb1, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6, 0xb7, 0xb8}
+	root := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: rootID, TraceFlags: trace.FlagsSampled})
+	child := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: childID, TraceFlags: trace.FlagsSampled})
+	start := time.Unix(1700000000, 123456789)
+
+	mount := resource.NewSchemaless(attribute.String("service.name", "uor-fuse-go"), attribute.String("uor.mount", "/mnt/data"))
+	other := resource.NewSchemaless(attribute.String("service.name", "uor-fuse-go"), attribute.String("uor.mount", "/mnt/models"))
+	fuseScope := instrumentation.Scope{Name: "github.com/uor-framework/uor-fuse-go/fs", Version: "v1"}
+	registryScope := instrumentation.Scope{Name: "github.com/uor-framework/uor-fuse-go/tracing"}
+
+	stubs := tracetest.SpanStubs{
+		{
+			Name:        "fuse.Read",
+			SpanContext: root,
+			SpanKind:    trace.SpanKindServer,
+			StartTime:   start,
+			EndTime:     start.Add(1500 * time.Microsecond),
+			Attributes: []attribute.KeyValue{
+				attribute.String("fuse.path", "/models/config.json"),
+				attribute.Int64("fuse.offset", 9007199254740993),
+				attribute.Float64("cache.ratio", 0.5),
+				attribute.Bool("cache.hit", false),
+				attribute.StringSlice("tags", []string{"a", "b"}),
+				attribute.Int64Slice("sizes", []int64{1, -2}),
+				attribute.Float64Slice("ratios", []float64{0.25}),
+				attribute.BoolSlice("flags", []bool{true}),
+			},
+			Events: []sdktrace.Event{
+				{Name: "lock acquired", Time: start.Add(time.Microsecond)},
+				{Name: "fetched", Time: start.Add(time.Millisecond), Attributes: []attribute.KeyValue{attribute.Int("bytes", 42)}},
+			},
+			Status:                 sdktrace.Status{Code: codes.Ok},
+			Resource:               mount,
+			InstrumentationLibrary: fuseScope,
+		},
+		{
+			Name:                   "registry.GetContent",
+			SpanContext:            child,
+			Parent:                 root,
+			SpanKind:               trace.SpanKindClient,
+			StartTime:              start.Add(10 * time.Microsecond),
+			EndTime:                start.Add(time.Millisecond),
+			Status:                 sdktrace.Status{Code: codes.Error, Description: "connection refused"},
+			Resource:               mount,
+			InstrumentationLibrary: registryScope,
+		},
+		{
+			Name:                   "fuse.Getattr",
+			SpanContext:            child,
+			SpanKind:               trace.SpanKindInternal,
+			StartTime:              start,
+			EndTime:                start,
+			Resource:               other,
+			InstrumentationLibrary: fuseScope,
+		},
+	}
+	return stubs.Snapshots()
+}
+
+// exportFile exports batches with a file exporter and returns the lines
+// written.
+func exportFile(t *testing.T, batches ...[]sdktrace.ReadOnlySpan) []string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	exporter, err := newFileExporter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, spans := range batches {
+		if err := exporter.ExportSpans(context.Background(), spans); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// TestFileExporterMapping checks the parts of the OTLP/JSON mapping that
+// differ from plain protobuf JSON: IDs are lowercase hex, 64-bit integers
+// are strings and enums are numbers, with OK and Error swapped from the
+// Go API.
+func TestFileExporterMapping(t *testing.T) {
+	lines := exportFile(t, testSpans())
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []map[string]json.RawMessage `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &request); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(request.ResourceSpans); got != 2 {
+		t.Fatalf("got %d resourceSpans, want 2", got)
+	}
+	var read, fetch map[string]json.RawMessage
+	for _, rs := range request.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				switch string(span["name"]) {
+				case `"fuse.Read"`:
+					read = span
+				case `"registry.GetContent"`:
+					fetch = span
+				}
+			}
+		}
+	}
+	if read == nil || fetch == nil {
+		t.Fatalf("spans missing from %s", lines[0])
+	}
+
+	tests := []struct {
+		name string
+		span map[string]json.RawMessage
+		key  string
+		want string
+	}{
+		{name: "TraceIDHex", span: read, key: "traceId", want: `"0102030405060708090a0b0c0d0e0f10"`},
+		{name: "SpanIDHex", span: read, key: "spanId", want: `"a1a2a3a4a5a6a7a8"`},
+		{name: "ParentSpanIDHex", span: fetch, key: "parentSpanId", want: `"a1a2a3a4a5a6a7a8"`},
+		{name: "StartTimeString", span: read, key: "startTimeUnixNano", want: `"1700000000123456789"`},
+		{name: "EndTimeString", span: read, key: "endTimeUnixNano", want: `"1700000000124956789"`},
+		{name: "KindServer", span: read, key: "kind", want: "2"},
+		{name: "KindClient", span: fetch, key: "kind", want: "3"},
+		{name: "StatusOk", span: read, key: "status", want: `{"code":1}`},
+		{name: "StatusError", span: fetch, key: "status", want: `{"code":2,"message":"connection refused"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.span[tt.key]); got != tt.want {
+				t.Errorf("%s = %s, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+	if _, ok := read["parentSpanId"]; ok {
+		t.Error("root span has a parentSpanId")
+	}
+	if !strings.Contains(string(read["attributes"]), `{"key":"fuse.offset","value":{"intValue":"9007199254740993"}}`) {
+		t.Errorf("int64 attribute not encoded as a string: %s", read["attributes"])
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	spans := testSpans()
+	lines := exportFile(t, spans, spans, nil)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want one per non-empty batch", len(lines))
+	}
+	for _, line := range lines {
+		if got := strings.Count(line, `"spanId"`); got != len(spans) {
+			t.Errorf("got %d spans in line, want %d: %s", got, len(spans), line)
+		}
+	}
+}
+
+func TestHTTPExporter(t *testing.T) {
+	var path, contentType string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	exporter, err := newHTTPExporter(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.ExportSpans(context.Background(), testSpans()); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1/traces" {
+		t.Errorf("posted to %q, want /v1/traces", path)
+	}
+	if contentType != "application/x-protobuf" {
+		t.Errorf("Content-Type = %q, want application/x-protobuf", contentType)
+	}
+	var traces tracepb.TracesData
+	if err := proto.Unmarshal(body, &traces); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, rs := range traces.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				names = append(names, span.Name)
+			}
+		}
+	}
+	if got := strings.Join(names, ","); len(names) != 3 || !strings.Contains(got, "registry.GetContent") {
+		t.Errorf("posted spans %s, want the 3 test spans", got)
+	}
+}
+
+func TestHTTPExporterErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	exporter, err := newHTTPExporter(server.URL + "/custom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.ExportSpans(context.Background(), testSpans()); err == nil {
+		t.Error("ExportSpans() succeeded on 400 Bad Request")
+	}
+
+	for _, endpoint := range []string{"localhost:4318", "grpc://localhost:4317", "http://[::1"} {
+		if _, err := newHTTPExporter(endpoint); err == nil {
+			t.Errorf("newHTTPExporter(%q) succeeded, want error", endpoint)
+		}
+	}
+}
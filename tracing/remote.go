@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"io"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/uor-framework/uor-client-go/nodes/collection"
+	"github.com/uor-framework/uor-client-go/registryclient"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer returns the tracer used for spans from this program. It uses
+// the global provider, so spans are dropped until Setup is called.
+func Tracer() trace.Tracer {
+	return otel.Tracer("github.com/uor-framework/uor-fuse-go")
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// InstrumentRemote returns a client that creates a span for each registry
+// request made through remote, as a child of the span in its context.
+func InstrumentRemote(remote registryclient.Remote) registryclient.Remote {
+	if remote == nil {
+		return nil
+	}
+	if _, ok := remote.(*tracedRemote); ok {
+		return remote
+	}
+	return &tracedRemote{Remote: remote}
+}
+
+type tracedRemote struct {
+	registryclient.Remote
+}
+
+func (r *tracedRemote) GetManifest(ctx context.Context, reference string) (ocispec.Descriptor, io.ReadCloser, error) {
+	ctx, span := Tracer().Start(ctx, "registry.GetManifest", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("uor.reference", reference)))
+	desc, rc, err := r.Remote.GetManifest(ctx, reference)
+	if err == nil {
+		span.SetAttributes(attribute.String("uor.digest", desc.Digest.String()))
+	}
+	End(span, err)
+	return desc, rc, err
+}
+
+func (r *tracedRemote) GetContent(ctx context.Context, reference string, desc ocispec.Descriptor) ([]byte, error) {
+	ctx, span := Tracer().Start(ctx, "registry.GetContent", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("uor.reference", reference),
+			attribute.String("uor.digest", desc.Digest.String()),
+			attribute.Int64("uor.size", desc.Size),
+		))
+	content, err := r.Remote.GetContent(ctx, reference, desc)
+	End(span, err)
+	return content, err
+}
+
+func (r *tracedRemote) LoadCollection(ctx context.Context, reference string) (collection.Collection, error) {
+	ctx, span := Tracer().Start(ctx, "registry.LoadCollection", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("uor.reference", reference)))
+	c, err := r.Remote.LoadCollection(ctx, reference)
+	End(span, err)
+	return c, err
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// serviceName identifies spans from this program.
+const serviceName = "uor-fuse-go"
+
+// Setup installs a global tracer provider exporting spans to an OTLP/HTTP
+// collector at endpoint and/or appending them to file, with the mount
+// point as a resource attribute. When neither is set tracing stays
+// disabled. The returned function flushes and stops exporting.
+func Setup(endpoint string, file string, mountPoint string) (func(context.Context) error, error) {
+	var exporters []sdktrace.SpanExporter
+	if endpoint != "" {
+		exporter, err := newHTTPExporter(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, exporter)
+	}
+	if file != "" {
+		exporter, err := newFileExporter(file)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, exporter)
+	}
+	if len(exporters) == 0 {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(serviceName),
+		attribute.String("uor.mount", mountPoint),
+	)
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	for _, exporter := range exporters {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// newHTTPExporter posts spans to an OTLP/HTTP collector. An endpoint
+// without a path, such as http://localhost:4318, uses /v1/traces.
+func newHTTPExporter(endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("trace endpoint %q must be an http or https URL", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	client := &httpClient{url: u.String(), client: &http.Client{Timeout: 10 * time.Second}}
+	return otlptrace.New(context.Background(), client)
+}
+
+// newFileExporter appends each batch of spans to path as one line of
+// OTLP/JSON, for offline analysis.
+func newFileExporter(path string) (sdktrace.SpanExporter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return otlptrace.New(context.Background(), &fileClient{file: file})
+}